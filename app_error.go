@@ -0,0 +1,145 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppError - Application error carrying the api error code, http status and cause.
+// Service layers can return it without depending on gin.
+type AppError struct {
+	Code    string
+	Message string
+	Details []ErrorDetail
+	Status  int
+	Err     error
+}
+
+var codeStatus = map[string]int{
+	BAD_REQUEST:              http.StatusBadRequest,
+	INVALID_ARGUMENT:         http.StatusBadRequest,
+	OUT_OF_RANGE:             http.StatusBadRequest,
+	UNAUTHENTICATED:          http.StatusUnauthorized,
+	ACCESS_DENIED:            http.StatusForbidden,
+	NOT_FOUND:                http.StatusNotFound,
+	NOT_ACCEPTABLE:           http.StatusNotAcceptable,
+	FAILED_PRECONDITION:      http.StatusPreconditionFailed,
+	ABORTED:                  http.StatusConflict,
+	ALREADY_EXISTS:           http.StatusConflict,
+	REFERENCE_INTEGRITY_FAIL: http.StatusConflict,
+	RESOURCE_EXHAUSTED:       http.StatusTooManyRequests,
+	CANCELLED:                499,
+	DATA_LOSS:                http.StatusInternalServerError,
+	UNKNOWN:                  http.StatusInternalServerError,
+	INTERNAL_SERVER_ERROR:    http.StatusInternalServerError,
+	NOT_IMPLEMENTED:          http.StatusNotImplemented,
+	UNAVAILABLE:              http.StatusServiceUnavailable,
+	DEADLINE_EXCEEDED:        http.StatusGatewayTimeout,
+}
+
+var codeMessage = map[string]string{
+	NOT_FOUND:                "Requested resource not found",
+	ALREADY_EXISTS:           "Resource already exists",
+	REFERENCE_INTEGRITY_FAIL: "Referenced resource does not exist",
+	CANCELLED:                "Request cancelled by the client",
+	INTERNAL_SERVER_ERROR:    "Internal server error",
+}
+
+// NewAppError - Create the application error for the code
+func NewAppError(code string, message string, details ...ErrorDetail) *AppError {
+	return &AppError{
+		Code:    code,
+		Message: message,
+		Details: details,
+		Status:  StatusFromCode(code),
+	}
+}
+
+// WrapAppError - Create the application error for the code keeping the cause
+func WrapAppError(err error, code string, message string) *AppError {
+	appErr := NewAppError(code, message)
+	appErr.Err = err
+
+	return appErr
+}
+
+// StatusFromCode - Get the http status for the error code
+func StatusFromCode(code string) int {
+	if status, ok := codeStatus[code]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (e *AppError) Error() string {
+	message := e.Message
+	if len(message) == 0 {
+		message = e.Code
+	}
+
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorData - Get the api error output for the application error
+func (e *AppError) ErrorData() *ErrorData {
+	message := e.Message
+
+	if len(message) == 0 {
+		message = codeMessage[e.Code]
+	}
+
+	if len(message) == 0 {
+		message = http.StatusText(e.HTTPStatus())
+	}
+
+	return &ErrorData{
+		Code:    e.Code,
+		Message: message,
+		Details: e.Details,
+	}
+}
+
+// HTTPStatus - Get the http status of the application error
+func (e *AppError) HTTPStatus() int {
+	if e.Status > 0 {
+		return e.Status
+	}
+
+	return StatusFromCode(e.Code)
+}
+
+// RespondError - Render the error in the standard envelope with the matching http status
+func RespondError(c *gin.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	var appErr *AppError
+
+	if !errors.As(err, &appErr) {
+		appErr = NewDbError(err, nil)
+	}
+
+	status := appErr.HTTPStatus()
+
+	if status >= http.StatusInternalServerError {
+		if log, ok := c.Get("log"); ok {
+			if microLog, ok := log.(*MicroLog); ok {
+				microLog.Logger().Error(err.Error())
+			}
+		}
+	}
+
+	ErrorResponseWitCode(c, status, appErr.ErrorData())
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestAppErrorData(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *AppError
+		status  int
+		message string
+	}{
+		{name: "code status", err: NewAppError(NOT_FOUND, ""), status: http.StatusNotFound, message: "Requested resource not found"},
+		{name: "explicit message", err: NewAppError(ALREADY_EXISTS, "Email is taken"), status: http.StatusConflict, message: "Email is taken"},
+		{name: "status text", err: NewAppError(RESOURCE_EXHAUSTED, ""), status: http.StatusTooManyRequests, message: "Too Many Requests"},
+		{name: "unknown code", err: NewAppError("TEAPOT", ""), status: http.StatusInternalServerError, message: "Internal Server Error"},
+		{name: "explicit status", err: &AppError{Code: NOT_FOUND, Status: http.StatusGone}, status: http.StatusGone, message: "Requested resource not found"},
+		{name: "cancelled", err: NewAppError(CANCELLED, ""), status: 499, message: "Request cancelled by the client"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if status := test.err.HTTPStatus(); status != test.status {
+				t.Errorf("status %d, want %d", status, test.status)
+			}
+
+			data := test.err.ErrorData()
+
+			if data.Code != test.err.Code || data.Message != test.message {
+				t.Errorf("error data %+v, want code %s message %q", data, test.err.Code, test.message)
+			}
+		})
+	}
+}
+
+func TestAppErrorWrap(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := fmt.Errorf("load: %w", WrapAppError(cause, UNAVAILABLE, "Store unavailable"))
+
+	var appErr *AppError
+
+	if !errors.As(err, &appErr) || appErr.Code != UNAVAILABLE {
+		t.Fatalf("errors.As found %v", appErr)
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("wrapped cause is not found with errors.Is")
+	}
+
+	if message := appErr.Error(); message != "Store unavailable: connection reset" {
+		t.Errorf("message %q", message)
+	}
+}
+
+func TestRespondError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "app error", err: NewAppError(ACCESS_DENIED, "No access"), status: http.StatusForbidden, code: ACCESS_DENIED},
+		{name: "wrapped app error", err: fmt.Errorf("update: %w", NewAppError(FAILED_PRECONDITION, "")), status: http.StatusPreconditionFailed, code: FAILED_PRECONDITION},
+		{name: "db error", err: gorm.ErrRecordNotFound, status: http.StatusNotFound, code: NOT_FOUND},
+		{name: "unknown error", err: errors.New("boom"), status: http.StatusInternalServerError, code: INTERNAL_SERVER_ERROR},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+
+			RespondError(c, test.err)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d", recorder.Code, test.status)
+			}
+
+			var response struct {
+				Status int       `json:"status"`
+				Error  ErrorData `json:"error"`
+			}
+
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.Status != test.status || response.Error.Code != test.code {
+				t.Errorf("response %+v, want status %d code %s", response, test.status, test.code)
+			}
+		})
+	}
+}
+
+func TestRespondErrorNil(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+
+	RespondError(c, nil)
+
+	if c.Writer.Written() {
+		t.Errorf("nil error wrote status %d", recorder.Code)
+	}
+}
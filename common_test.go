@@ -0,0 +1,33 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/utils/tests"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// dryRunDialector builds the SQL of the statements without a database
+type dryRunDialector struct {
+	tests.DummyDialector
+}
+
+func (d dryRunDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	return nil
+}
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(dryRunDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
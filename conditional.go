@@ -0,0 +1,159 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EntityTag - Format the version as strong or weak entity tag
+func EntityTag(version string, weak bool) string {
+	tag := `"` + strings.Trim(version, `"`) + `"`
+
+	if weak {
+		return "W/" + tag
+	}
+
+	return tag
+}
+
+// SetEntityTag - Use the entity version, e.g. the version column or updated_at, as the ETag of
+// the success response in place of the hash of the rendered body
+func SetEntityTag(c *gin.Context, version string, weak bool) {
+	c.Set("etag", EntityTag(version, weak))
+}
+
+// SetLastModified - Set the Last-Modified of the success response to answer If-Modified-Since
+func SetLastModified(c *gin.Context, modified time.Time) {
+	c.Set("lastModified", modified)
+}
+
+// CheckIfMatch - Check the If-Match precondition of a write against the current entity version.
+// It responds 412 and returns false when the version differs, true without If-Match. The GET of
+// the resource must use SetEntityTag with the version, weak or strong, so that the client can
+// send back its ETag; the hash ETag of the body can not be checked against the version.
+func CheckIfMatch(c *gin.Context, version string) bool {
+	ifMatch := c.GetHeader("If-Match")
+
+	// the version tag matches whether SetEntityTag made it weak or strong
+	if len(ifMatch) == 0 || matchEntityTag(ifMatch, EntityTag(version, false), true) {
+		return true
+	}
+
+	PreconditionFailed(c, "Resource has been modified, version "+version+" is current")
+
+	return false
+}
+
+// notModified sets the ETag and Last-Modified of GET responses and answers 304
+// when the If-None-Match or If-Modified-Since of the request still holds.
+// bodyTag is called for the ETag when the handler did not set the entity version.
+func notModified(c *gin.Context, status int, bodyTag func() string) bool {
+	method := c.Request.Method
+
+	if status != http.StatusOK || (method != http.MethodGet && method != http.MethodHead) {
+		return false
+	}
+
+	etag := c.GetString("etag")
+
+	if len(etag) == 0 {
+		etag = bodyTag()
+	}
+
+	if len(etag) > 0 {
+		c.Header("ETag", etag)
+	}
+
+	var lastModified time.Time
+
+	if value, ok := c.Get("lastModified"); ok {
+		lastModified, _ = value.(time.Time)
+	}
+
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is present
+	if ifNoneMatch := c.GetHeader("If-None-Match"); len(ifNoneMatch) > 0 {
+		if len(etag) == 0 || !matchEntityTag(ifNoneMatch, etag, true) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+
+	return true
+}
+
+// matchEntityTag compares the tag with the If-Match or If-None-Match list,
+// weak comparison ignores the W/ prefix while strong comparison never matches weak tags
+func matchEntityTag(header string, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+				return true
+			}
+			continue
+		}
+
+		if candidate == tag && !strings.HasPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bodyEntityTag returns the strong entity tag of the encoded body
+func bodyEntityTag(contentType string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentType))
+	hash.Write(body)
+
+	return EntityTag(base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:18]), false)
+}
+
+// withoutRequestID returns the response without the request id which differs on every
+// request, so that the body entity tag is the same for the same data
+func withoutRequestID(response interface{}) interface{} {
+	switch r := response.(type) {
+	case Response:
+		r.RequestId = ""
+		return r
+	case ResponseWithPage:
+		r.RequestId = ""
+		return r
+	case ResponseWithFilter:
+		r.RequestId = ""
+		return r
+	case map[string]interface{}:
+		body := make(map[string]interface{}, len(r))
+		for key, value := range r {
+			if key != "requestId" {
+				body[key] = value
+			}
+		}
+		return body
+	}
+
+	return response
+}
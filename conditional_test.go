@@ -0,0 +1,106 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotModified(t *testing.T) {
+	router := gin.New()
+	router.GET("/items/1", func(c *gin.Context) {
+		SuccessResponse(c, "item", map[string]interface{}{"id": 1})
+	})
+
+	get := func(requestID string, ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		request.Header.Set("X-Request-Id", requestID)
+		if len(ifNoneMatch) > 0 {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	first := get("request-1", "")
+	etag := first.Header().Get("ETag")
+
+	if first.Code != http.StatusOK || len(etag) == 0 {
+		t.Fatalf("response = %d with ETag %q, want 200 with ETag", first.Code, etag)
+	}
+
+	if second := get("request-2", etag); second.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304 for the ETag of another request", second.Code)
+	}
+
+	if changed := get("request-3", `"other"`); changed.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 for another ETag", changed.Code)
+	}
+}
+
+func TestNotModifiedRequestIDInBody(t *testing.T) {
+	id := 1
+	router := gin.New()
+	router.GET("/items/1", func(c *gin.Context) {
+		SuccessResponse(c, "item", map[string]interface{}{"id": id})
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		request.Header.Set("X-Request-Id", "1")
+		if len(ifNoneMatch) > 0 {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	etag := get("").Header().Get("ETag")
+	id = 11
+
+	if changed := get(etag); changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("status = %d with ETag %q, want 200 with a new ETag for the changed body", changed.Code, etag)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		ok      bool
+	}{
+		{name: "without If-Match", ifMatch: "", ok: true},
+		{name: "strong version tag", ifMatch: EntityTag("3", false), ok: true},
+		{name: "weak version tag of the GET", ifMatch: EntityTag("3", true), ok: true},
+		{name: "any", ifMatch: "*", ok: true},
+		{name: "old version", ifMatch: EntityTag("2", false), ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPut, "/items/1", nil)
+
+			if len(test.ifMatch) > 0 {
+				c.Request.Header.Set("If-Match", test.ifMatch)
+			}
+
+			if ok := CheckIfMatch(c, "3"); ok != test.ok {
+				t.Errorf("ok = %v, want %v", ok, test.ok)
+			}
+
+			if !test.ok && recorder.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want 412", recorder.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// CountExact runs COUNT(*) on the filtered query
+	CountExact = "exact"
+	// CountEstimated reads the table statistics, ignoring the filters
+	CountEstimated = "estimated"
+	// CountCapped counts up to the configured cap ("10000+")
+	CountCapped = "capped"
+	// CountNone skips the count and fetches one extra row to find whether more rows exist
+	CountNone = "none"
+)
+
+// PageCount - Row count of the page query
+type PageCount struct {
+	Mode    string
+	Total   int64
+	HasMore bool
+}
+
+func validCountMode(mode string, defaultMode string) string {
+	switch strings.ToLower(mode) {
+	case CountExact, CountEstimated, CountCapped, CountNone:
+		return strings.ToLower(mode)
+	}
+
+	return defaultMode
+}
+
+func (page Page) fetchSize() int {
+	if page.CountMode == CountNone {
+		return page.Size + 1
+	}
+
+	return page.Size
+}
+
+// CountPage - Count the rows of the filtered query (without the pagination scope)
+// in the count mode of the request. The returned mode is the one actually used,
+// estimated falls back to exact when the dialect has no statistics.
+func CountPage(db *gorm.DB, pagination Pagination) (PageCount, error) {
+	config := pagination.getConfig()
+	count := PageCount{Mode: ValidPage(pagination, nil).CountMode}
+
+	switch count.Mode {
+	case CountNone:
+		return count, nil
+	case CountEstimated:
+		if total, ok := estimatedCount(db); ok {
+			count.Total = total
+			return count, nil
+		}
+	case CountCapped:
+		limited := db.Session(&gorm.Session{}).Select("1").Limit(int(config.CountCap) + 1)
+		err := db.Session(&gorm.Session{NewDB: true}).Table("(?) AS capped_count", limited).Count(&count.Total).Error
+
+		if count.Total <= config.CountCap {
+			count.Mode = CountExact
+		} else {
+			count.Total = config.CountCap
+		}
+
+		return count, err
+	}
+
+	count.Mode = CountExact
+	err := db.Session(&gorm.Session{}).Count(&count.Total).Error
+
+	return count, err
+}
+
+// estimatedCount reads the row estimate of the query table from the database statistics
+func estimatedCount(db *gorm.DB) (int64, bool) {
+	table := statementTable(db)
+
+	if len(table) == 0 {
+		return 0, false
+	}
+
+	var total int64
+	var err error
+	raw := db.Session(&gorm.Session{NewDB: true})
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		err = raw.Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", table).Scan(&total).Error
+	case "mysql":
+		err = raw.Raw("SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Scan(&total).Error
+	default:
+		return 0, false
+	}
+
+	// postgres reports -1 for tables which were never analyzed
+	if err != nil || total < 0 {
+		return 0, false
+	}
+
+	return total, true
+}
+
+// TrimPage - Remove the extra row fetched in the none count mode and set HasMore of the
+// count. rows must be a pointer to the slice loaded with the pagination scope; returns
+// whether more rows exist.
+func (count *PageCount) TrimPage(pagination Pagination, rows interface{}) bool {
+	page := ValidPage(pagination, nil)
+	value := reflect.ValueOf(rows)
+
+	if page.CountMode != CountNone || value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return false
+	}
+
+	slice := value.Elem()
+	count.HasMore = slice.Len() > page.Size
+
+	if count.HasMore {
+		slice.Set(slice.Slice(0, page.Size))
+	}
+
+	return count.HasMore
+}
+
+// PageInfoWithCount - Gives the pagination info to API for the count mode
+func PageInfoWithCount(pagination Pagination, count PageCount) PageResult {
+	pageResult := PageInfo(pagination, count.Total)
+	pageResult.CountMode = count.Mode
+
+	switch count.Mode {
+	case CountNone:
+		pageResult.TotalCount = 0
+		pageResult.TotalPages = 0
+		pageResult.HasMore = count.HasMore
+	case CountCapped:
+		// the count reached the cap so more rows than the cap exist
+		pageResult.HasMore = pageResult.PageOffset-1+pageResult.PageSize <= int(count.Total) || count.HasMore
+	default:
+		return pageResult
+	}
+
+	pageResult.IsLast = 1
+
+	if pageResult.HasMore {
+		pageResult.IsLast = 0
+	}
+
+	return pageResult
+}
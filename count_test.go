@@ -0,0 +1,74 @@
+package common
+
+import "testing"
+
+func TestPageInfoWithCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  string
+		mode    string
+		count   PageCount
+		hasMore bool
+		isLast  int
+	}{
+		{
+			name:    "exact count on the last page",
+			number:  "4",
+			count:   PageCount{Mode: CountExact, Total: 100},
+			hasMore: false,
+			isLast:  1,
+		},
+		{
+			name:    "capped count on the last page of the cap",
+			number:  "400",
+			mode:    CountCapped,
+			count:   PageCount{Mode: CountCapped, Total: 10000},
+			hasMore: true,
+			isLast:  0,
+		},
+		{
+			name:    "capped count under the cap",
+			number:  "4",
+			mode:    CountCapped,
+			count:   PageCount{Mode: CountExact, Total: 100},
+			hasMore: false,
+			isLast:  1,
+		},
+		{
+			name:    "no count with more rows",
+			number:  "1",
+			mode:    CountNone,
+			count:   PageCount{Mode: CountNone, HasMore: true},
+			hasMore: true,
+			isLast:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pagination := Pagination{PageNumber: test.number, PageSize: "25", PageCount: test.mode}
+			pageResult := PageInfoWithCount(pagination, test.count)
+
+			if pageResult.HasMore != test.hasMore || pageResult.IsLast != test.isLast {
+				t.Errorf("hasMore = %v, isLast = %d, want %v, %d", pageResult.HasMore, pageResult.IsLast, test.hasMore, test.isLast)
+			}
+		})
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	pagination := Pagination{PageNumber: "1", PageSize: "2", PageCount: CountNone}
+	rows := []int{1, 2, 3}
+	count := PageCount{Mode: CountNone}
+
+	if !count.TrimPage(pagination, &rows) || !count.HasMore || len(rows) != 2 {
+		t.Errorf("rows = %v, hasMore = %v, want 2 rows and more", rows, count.HasMore)
+	}
+
+	rows = []int{1}
+	count = PageCount{Mode: CountNone}
+
+	if count.TrimPage(pagination, &rows) || count.HasMore || len(rows) != 1 {
+		t.Errorf("rows = %v, hasMore = %v, want 1 row and no more", rows, count.HasMore)
+	}
+}
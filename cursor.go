@@ -0,0 +1,409 @@
+package common
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	CursorNext = "next"
+	CursorPrev = "prev"
+)
+
+// ErrInvalidCursor - Page cursor is malformed, tampered or created for another order
+var ErrInvalidCursor = &AppError{
+	Code:    INVALID_ARGUMENT,
+	Message: "Invalid page cursor",
+	Status:  http.StatusBadRequest,
+	Details: []ErrorDetail{{Code: "Invalid", Target: "pageCursor", Message: "Page cursor is not valid"}},
+}
+
+// ErrCursorOrder - Order on a joined or JSON column requested with the cursor pagination
+var ErrCursorOrder = &AppError{
+	Code:    INVALID_ARGUMENT,
+	Message: "Invalid page order",
+	Status:  http.StatusBadRequest,
+	Details: []ErrorDetail{{Code: "Invalid", Target: "pageOrder", Message: "Cursor pagination can not order by joined or JSON fields"}},
+}
+
+var (
+	cursorSecret      []byte
+	cursorSecretOnce  sync.Once
+	cursorSchemaCache = &sync.Map{}
+)
+
+// Cursor - Position of a row in the keyset pagination
+type Cursor struct {
+	Fields    []CursorField `json:"f"`
+	Direction string        `json:"d"`
+}
+
+// CursorField - Order by column value of the cursor row. Type is "time" for time values
+// which are encoded as RFC 3339 strings.
+type CursorField struct {
+	Column    string      `json:"c"`
+	Direction string      `json:"o"`
+	Value     interface{} `json:"v"`
+	Type      string      `json:"t,omitempty"`
+}
+
+const cursorTypeTime = "time"
+
+// CursorPageResult - Cursor pagination output to API
+type CursorPageResult struct {
+	PageSize   int    `json:"pageSize"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	//Links are set by the page responses from the request url
+	Links *PageLinks `json:"links,omitempty"`
+}
+
+// SetCursorSecret - Set the key used to sign the page cursors
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+// EncodeCursor - Create the opaque signed cursor
+func EncodeCursor(cursor Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + signCursor(encoded), nil
+}
+
+// DecodeCursor - Verify and decode the opaque cursor
+func DecodeCursor(value string) (Cursor, error) {
+	var cursor Cursor
+
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(signCursor(parts[0]))) {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&cursor); err != nil || len(cursor.Fields) == 0 {
+		return cursor, ErrInvalidCursor
+	}
+
+	if cursor.Direction != CursorNext && cursor.Direction != CursorPrev {
+		return cursor, ErrInvalidCursor
+	}
+
+	for i, field := range cursor.Fields {
+		switch value := field.Value.(type) {
+		case json.Number:
+			if number, err := value.Int64(); err == nil {
+				cursor.Fields[i].Value = number
+			} else if number, err := value.Float64(); err == nil {
+				cursor.Fields[i].Value = number
+			}
+		case string:
+			if field.Type != cursorTypeTime {
+				continue
+			}
+
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return cursor, ErrInvalidCursor
+			}
+
+			cursor.Fields[i].Value = t
+		}
+	}
+
+	return cursor, nil
+}
+
+// getCursorSecret returns the signing key, read from PAGINATION_CURSOR_SECRET on first use.
+// Cursors signed with the random fallback key are only valid within the running
+// instance, so set the variable when the service runs on multiple instances.
+func getCursorSecret() []byte {
+	cursorSecretOnce.Do(func() {
+		if len(cursorSecret) > 0 {
+			return
+		}
+
+		cursorSecret = []byte(GetEnv("PAGINATION_CURSOR_SECRET", ""))
+
+		if len(cursorSecret) == 0 {
+			log.Println("PAGINATION_CURSOR_SECRET is not set, page cursors are signed with a random key " +
+				"and are rejected by the other instances of the service")
+
+			cursorSecret = make([]byte, 32)
+			_, _ = rand.Read(cursorSecret)
+		}
+	})
+
+	return cursorSecret
+}
+
+func signCursor(payload string) string {
+	mac := hmac.New(sha256.New, getCursorSecret())
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cursorPage returns the valid page, the order and the decoded cursor of the request.
+// The cursor is rejected when its order is not the allowed order of the request.
+func cursorPage(pagination Pagination, allowedFields map[string]interface{}) (Page, []sortField, *Cursor, error) {
+	page := ValidPage(pagination, allowedFields)
+	config := pagination.getConfig()
+	tieBreaker := config.TieBreaker
+	direction := config.DefaultDirection
+	hasTieBreaker := false
+
+	// the keyset needs a unique column even when the tie breaker is disabled
+	if len(tieBreaker) == 0 || tieBreaker == "-" {
+		tieBreaker = "id"
+	}
+
+	fields := make([]sortField, 0)
+
+	for _, field := range parseOrderFields(pagination.PageOrder, allowedFields, config) {
+		// keyset comparison is done on the root table columns only
+		if len(field.Join) > 0 || len(field.JSONPath) > 0 {
+			return page, nil, nil, ErrCursorOrder
+		}
+
+		direction = field.Direction
+		if field.Column == tieBreaker {
+			hasTieBreaker = true
+		}
+
+		fields = append(fields, sortField{Column: field.Column, Direction: field.Direction})
+	}
+
+	if !hasTieBreaker {
+		fields = append(fields, sortField{Column: tieBreaker, Direction: direction})
+	}
+
+	if len(pagination.PageCursor) == 0 {
+		return page, fields, nil, nil
+	}
+
+	cursor, err := DecodeCursor(pagination.PageCursor)
+	if err != nil {
+		return page, nil, nil, err
+	}
+
+	if len(cursor.Fields) != len(fields) {
+		return page, nil, nil, ErrInvalidCursor
+	}
+
+	for i, field := range cursor.Fields {
+		if field.Column != fields[i].Column || field.Direction != fields[i].Direction {
+			return page, nil, nil, ErrInvalidCursor
+		}
+	}
+
+	return page, fields, &cursor, nil
+}
+
+// CursorPaginate - Do the db keyset pagination query. Fetches one row more than
+// the page size so that CursorPageInfo can find whether more rows exist.
+// Order columns must be root table columns without NULL values.
+func CursorPaginate(pagination Pagination, allowedFields map[string]interface{}) func(db *gorm.DB) *gorm.DB {
+	page, fields, cursor, err := cursorPage(pagination, allowedFields)
+
+	return func(db *gorm.DB) *gorm.DB {
+		if err != nil {
+			return scopeError(db, err)
+		}
+
+		reverse := cursor != nil && cursor.Direction == CursorPrev
+
+		if cursor != nil {
+			query, vars := cursorCondition(cursor)
+			db = db.Where(query, vars...)
+		}
+
+		orders := make([]string, len(fields))
+		for i, field := range fields {
+			direction := field.Direction
+			if reverse {
+				direction = reverseDirection(direction)
+			}
+			orders[i] = field.Column + " " + direction
+		}
+
+		return db.Order(strings.Join(orders, ", ")).Limit(page.Size + 1)
+	}
+}
+
+// cursorCondition builds the tuple comparison for the multi column order
+// (a > ?) OR (a = ? AND b < ?) ...
+func cursorCondition(cursor *Cursor) (string, []interface{}) {
+	conditions := make([]string, 0, len(cursor.Fields))
+	vars := make([]interface{}, 0)
+
+	for i, field := range cursor.Fields {
+		parts := make([]string, 0, i+1)
+
+		for _, previous := range cursor.Fields[:i] {
+			parts = append(parts, previous.Column+" = ?")
+			vars = append(vars, previous.Value)
+		}
+
+		ascending := field.Direction == "ASC"
+		if cursor.Direction == CursorPrev {
+			ascending = !ascending
+		}
+
+		operator := "<"
+		if ascending {
+			operator = ">"
+		}
+
+		parts = append(parts, field.Column+" "+operator+" ?")
+		vars = append(vars, field.Value)
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", vars
+}
+
+func reverseDirection(direction string) string {
+	if direction == "ASC" {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+// CursorPageInfo - Gives the cursor pagination info to API. rows must be a pointer
+// to the slice loaded with CursorPaginate; the extra row is removed and rows
+// loaded backwards are restored to the requested order.
+func CursorPageInfo(pagination Pagination, allowedFields map[string]interface{}, rows interface{}) (CursorPageResult, error) {
+	var pageResult CursorPageResult
+
+	page, fields, cursor, err := cursorPage(pagination, allowedFields)
+	if err != nil {
+		return pageResult, err
+	}
+
+	pageResult.PageSize = page.Size
+
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return pageResult, errors.New("rows must be a pointer to slice")
+	}
+
+	slice := value.Elem()
+	hasMore := slice.Len() > page.Size
+
+	if hasMore {
+		slice.Set(slice.Slice(0, page.Size))
+	}
+
+	backward := cursor != nil && cursor.Direction == CursorPrev
+
+	if backward {
+		for i, j := 0, slice.Len()-1; i < j; i, j = i+1, j-1 {
+			first, last := slice.Index(i).Interface(), slice.Index(j).Interface()
+			slice.Index(i).Set(reflect.ValueOf(last))
+			slice.Index(j).Set(reflect.ValueOf(first))
+		}
+	}
+
+	pageResult.HasMore = hasMore
+
+	if slice.Len() == 0 {
+		return pageResult, nil
+	}
+
+	if backward || hasMore {
+		if pageResult.NextCursor, err = rowCursor(slice.Index(slice.Len()-1), fields, CursorNext); err != nil {
+			return pageResult, err
+		}
+	}
+
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		if pageResult.PrevCursor, err = rowCursor(slice.Index(0), fields, CursorPrev); err != nil {
+			return pageResult, err
+		}
+	}
+
+	return pageResult, nil
+}
+
+// rowCursor creates the cursor from the order column values of the row
+func rowCursor(row reflect.Value, fields []sortField, direction string) (string, error) {
+	cursor := Cursor{Direction: direction, Fields: make([]CursorField, len(fields))}
+
+	for i, field := range fields {
+		value, err := rowColumnValue(row, field.Column)
+		if err != nil {
+			return "", err
+		}
+
+		cursor.Fields[i] = CursorField{Column: field.Column, Direction: field.Direction, Value: value}
+
+		switch v := value.(type) {
+		case time.Time:
+			cursor.Fields[i].Type = cursorTypeTime
+		case *time.Time:
+			if v != nil {
+				cursor.Fields[i].Type = cursorTypeTime
+			}
+		}
+	}
+
+	return EncodeCursor(cursor)
+}
+
+// rowColumnValue returns the value of the db column from a struct or map row
+func rowColumnValue(row reflect.Value, column string) (interface{}, error) {
+	for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+		row = row.Elem()
+	}
+
+	if row.Kind() == reflect.Map {
+		value := row.MapIndex(reflect.ValueOf(column))
+		if !value.IsValid() {
+			return nil, errors.New("column " + column + " not found in row")
+		}
+
+		return value.Interface(), nil
+	}
+
+	rowSchema, err := schema.Parse(row.Addr().Interface(), cursorSchemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+
+	field := rowSchema.LookUpField(column)
+	if field == nil {
+		return nil, errors.New("column " + column + " not found in row")
+	}
+
+	value, _ := field.ValueOf(row)
+
+	return value, nil
+}
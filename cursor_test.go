@@ -0,0 +1,193 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	SetCursorSecret([]byte("test-secret"))
+
+	cursor := Cursor{
+		Direction: CursorNext,
+		Fields: []CursorField{
+			{Column: "name", Direction: "ASC", Value: "apple"},
+			{Column: "id", Direction: "ASC", Value: int64(42)},
+		},
+	}
+
+	encoded, err := EncodeCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeCursor(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("decoded = %#v, want %#v", decoded, cursor)
+	}
+
+	payload := strings.Split(encoded, ".")[0]
+	tampered, _ := EncodeCursor(Cursor{Direction: CursorNext, Fields: []CursorField{{Column: "id", Direction: "ASC", Value: 1}}})
+
+	invalid := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "empty", cursor: ""},
+		{name: "no signature", cursor: payload},
+		{name: "signature of another payload", cursor: payload + "." + strings.Split(tampered, ".")[1]},
+		{name: "not base64", cursor: "!!!." + strings.Split(encoded, ".")[1]},
+	}
+
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeCursor(test.cursor); err != ErrInvalidCursor {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorValueTypes(t *testing.T) {
+	SetCursorSecret([]byte("test-secret"))
+
+	type Event struct {
+		ID        uint
+		Name      string
+		CreatedAt time.Time
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := Event{ID: 7, Name: "2024-01-01T00:00:00Z", CreatedAt: createdAt}
+	fields := []sortField{{Column: "name", Direction: "ASC"}, {Column: "created_at", Direction: "ASC"}, {Column: "id", Direction: "ASC"}}
+
+	encoded, err := rowCursor(reflect.ValueOf(&row), fields, CursorNext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := DecodeCursor(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []interface{}{"2024-01-01T00:00:00Z", createdAt, int64(7)}
+
+	for i, field := range cursor.Fields {
+		if !reflect.DeepEqual(field.Value, want[i]) {
+			t.Errorf("%s = %#v, want %#v", field.Column, field.Value, want[i])
+		}
+	}
+}
+
+func TestCursorPaginate(t *testing.T) {
+	SetCursorSecret([]byte("test-secret"))
+
+	type Product struct {
+		ID   uint
+		Name string
+	}
+
+	allowedFields := map[string]interface{}{
+		"name":             "true",
+		"category.name":    SortField{Column: "categories.name", Join: "LEFT JOIN categories ON categories.id = products.category_id"},
+		"attributes.color": SortField{Column: "attributes", JSONPath: "color"},
+	}
+
+	cursor := func(direction string, fields ...CursorField) string {
+		encoded, err := EncodeCursor(Cursor{Direction: direction, Fields: fields})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name       string
+		pagination Pagination
+		sql        string
+		vars       []interface{}
+		err        error
+	}{
+		{
+			name:       "first page",
+			pagination: Pagination{PageSize: "10", PageOrder: "name asc"},
+			sql:        "SELECT * FROM `products` ORDER BY name ASC, id ASC LIMIT 11",
+		},
+		{
+			name: "next page",
+			pagination: Pagination{PageSize: "10", PageOrder: "name asc", PageCursor: cursor(CursorNext,
+				CursorField{Column: "name", Direction: "ASC", Value: "apple"},
+				CursorField{Column: "id", Direction: "ASC", Value: 7},
+			)},
+			sql:  "SELECT * FROM `products` WHERE ((name > ?) OR (name = ? AND id > ?)) ORDER BY name ASC, id ASC LIMIT 11",
+			vars: []interface{}{"apple", "apple", int64(7)},
+		},
+		{
+			name: "previous page",
+			pagination: Pagination{PageSize: "10", PageOrder: "name desc", PageCursor: cursor(CursorPrev,
+				CursorField{Column: "name", Direction: "DESC", Value: "apple"},
+				CursorField{Column: "id", Direction: "DESC", Value: 7},
+			)},
+			sql:  "SELECT * FROM `products` WHERE ((name > ?) OR (name = ? AND id > ?)) ORDER BY name ASC, id ASC LIMIT 11",
+			vars: []interface{}{"apple", "apple", int64(7)},
+		},
+		{
+			name: "cursor of another order",
+			pagination: Pagination{PageSize: "10", PageOrder: "name desc", PageCursor: cursor(CursorNext,
+				CursorField{Column: "name", Direction: "ASC", Value: "apple"},
+				CursorField{Column: "id", Direction: "ASC", Value: 7},
+			)},
+			err: ErrInvalidCursor,
+		},
+		{
+			name: "cursor of a column which is not allowed",
+			pagination: Pagination{PageSize: "10", PageCursor: cursor(CursorNext,
+				CursorField{Column: "secret", Direction: "ASC", Value: "x"},
+				CursorField{Column: "id", Direction: "ASC", Value: 7},
+			)},
+			err: ErrInvalidCursor,
+		},
+		{
+			name:       "joined order field",
+			pagination: Pagination{PageSize: "10", PageOrder: "category.name asc"},
+			err:        ErrCursorOrder,
+		},
+		{
+			name:       "JSON order field",
+			pagination: Pagination{PageSize: "10", PageOrder: "attributes.color desc"},
+			err:        ErrCursorOrder,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt := dryRunDB(t).Scopes(CursorPaginate(test.pagination, allowedFields)).Find(&[]Product{}).Statement
+
+			if test.err != nil {
+				if stmt.Error != test.err {
+					t.Errorf("err = %v, want %v", stmt.Error, test.err)
+				}
+				return
+			}
+
+			if stmt.Error != nil {
+				t.Fatal(stmt.Error)
+			}
+
+			if sql := stmt.SQL.String(); sql != test.sql {
+				t.Errorf("sql = %s, want %s", sql, test.sql)
+			}
+
+			if len(test.vars) > 0 && !reflect.DeepEqual(stmt.Vars, test.vars) {
+				t.Errorf("vars = %#v, want %#v", stmt.Vars, test.vars)
+			}
+		})
+	}
+}
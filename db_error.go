@@ -0,0 +1,311 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+var (
+	mysqlErrorPattern = regexp.MustCompile(`^Error (\d+)`)
+	sqlStatePattern   = regexp.MustCompile(`SQLSTATE ([0-9A-Z]{5})`)
+)
+
+// Postgres SQLSTATE codes mapped to the api error code
+var postgresErrorCodes = map[string]string{
+	"23505": ALREADY_EXISTS,
+	"23503": REFERENCE_INTEGRITY_FAIL,
+	"23502": INVALID_ARGUMENT,
+	"23514": INVALID_ARGUMENT,
+	"22001": INVALID_ARGUMENT,
+	"40001": ABORTED,
+	"40P01": ABORTED,
+	"57014": DEADLINE_EXCEEDED,
+}
+
+// MySQL error numbers mapped to the api error code
+var mysqlErrorCodes = map[int]string{
+	1062: ALREADY_EXISTS,
+	1451: REFERENCE_INTEGRITY_FAIL,
+	1452: REFERENCE_INTEGRITY_FAIL,
+	1048: INVALID_ARGUMENT,
+	1364: INVALID_ARGUMENT,
+	1406: INVALID_ARGUMENT,
+	3819: INVALID_ARGUMENT,
+	1205: ABORTED,
+	1213: ABORTED,
+	3024: DEADLINE_EXCEEDED,
+	1317: DEADLINE_EXCEEDED,
+}
+
+// SQLite error messages mapped to the api error code
+var sqliteErrorCodes = map[string]string{
+	"UNIQUE constraint failed":      ALREADY_EXISTS,
+	"PRIMARY KEY constraint failed": ALREADY_EXISTS,
+	"FOREIGN KEY constraint failed": REFERENCE_INTEGRITY_FAIL,
+	"NOT NULL constraint failed":    INVALID_ARGUMENT,
+	"CHECK constraint failed":       INVALID_ARGUMENT,
+	"database is locked":            ABORTED,
+	"interrupted":                   DEADLINE_EXCEEDED,
+}
+
+// CheckDbError - Classify the database error of MySQL, Postgres or SQLite into the api error code
+func CheckDbError(err error) string {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NOT_FOUND
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DEADLINE_EXCEEDED
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return CANCELLED
+	}
+
+	if code := postgresErrorCode(err); len(code) > 0 {
+		return code
+	}
+
+	if code := mysqlErrorCode(err); len(code) > 0 {
+		return code
+	}
+
+	if code := sqliteErrorCode(err); len(code) > 0 {
+		return code
+	}
+
+	if strings.HasPrefix(err.Error(), "record not found") {
+		return NOT_FOUND
+	}
+
+	return INTERNAL_SERVER_ERROR
+}
+
+// sqlState returns the SQLSTATE of the postgres driver error (pgconn and lib/pq)
+func sqlState(err error) string {
+	var stateErr interface{ SQLState() string }
+
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+
+	if code := dbErrorField(err, "Code"); len(code) == 5 {
+		return code
+	}
+
+	if matches := sqlStatePattern.FindStringSubmatch(err.Error()); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+func postgresErrorCode(err error) string {
+	return postgresErrorCodes[sqlState(err)]
+}
+
+func mysqlErrorCode(err error) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		matches := mysqlErrorPattern.FindStringSubmatch(e.Error())
+		if len(matches) != 2 {
+			continue
+		}
+
+		number, _ := strconv.Atoi(matches[1])
+		return mysqlErrorCodes[number]
+	}
+
+	return ""
+}
+
+// sqliteErrorCode matches the message of the driver error, the constraint messages
+// are followed by the failed columns, e.g. "UNIQUE constraint failed: users.email"
+func sqliteErrorCode(err error) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		message := e.Error()
+
+		for prefix, code := range sqliteErrorCodes {
+			if message == prefix || strings.HasPrefix(message, prefix+": ") {
+				return code
+			}
+		}
+	}
+
+	return ""
+}
+
+// dbErrorField returns the string field of the driver error found in the error chain
+func dbErrorField(err error, names ...string) string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		value := reflect.ValueOf(e)
+
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				break
+			}
+			value = value.Elem()
+		}
+
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+
+		for _, name := range names {
+			field := value.FieldByName(name)
+
+			if field.IsValid() && field.Kind() == reflect.String && len(field.String()) > 0 {
+				return field.String()
+			}
+		}
+	}
+
+	return ""
+}
+
+var (
+	mysqlDuplicatePattern = regexp.MustCompile(`Duplicate entry '(.*)' for key '([^']+)'`)
+	mysqlForeignPattern   = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\)")
+	postgresKeyPattern    = regexp.MustCompile(`Key \(([^)]+)\)=\((.*)\) (already exists|is not present|is still referenced)`)
+	sqliteColumnsPattern  = regexp.MustCompile(`(?:UNIQUE|PRIMARY KEY|NOT NULL) constraint failed: (.+)$`)
+)
+
+// NewDbError - Create the application error for the database error including the offending fields.
+// fieldMap maps a constraint or column name to the api field name.
+func NewDbError(err error, fieldMap map[string]string) *AppError {
+	appErr := WrapAppError(err, CheckDbError(err), "")
+	appErr.Details = DbErrorDetails(err, fieldMap)
+
+	return appErr
+}
+
+// DbErrorDetails - Extract the offending fields of duplicate key and foreign key errors.
+// fieldMap maps a constraint or column name to the api field name.
+func DbErrorDetails(err error, fieldMap map[string]string) []ErrorDetail {
+	if err == nil {
+		return nil
+	}
+
+	code := CheckDbError(err)
+
+	if code != ALREADY_EXISTS && code != REFERENCE_INTEGRITY_FAIL {
+		return nil
+	}
+
+	constraint, columns := dbErrorColumns(err)
+
+	if len(columns) == 0 && len(constraint) == 0 {
+		return nil
+	}
+
+	detailCode := "Unique"
+	message := "already exists"
+
+	if code == REFERENCE_INTEGRITY_FAIL {
+		detailCode = "Reference"
+		message = "reference is invalid"
+	}
+
+	// the index name of MySQL is not a field, the target is left empty unless it is mapped
+	if len(columns) == 0 {
+		columns = []string{""}
+	}
+
+	details := make([]ErrorDetail, 0, len(columns))
+
+	for _, column := range columns {
+		target := dbErrorTarget(constraint, column, fieldMap)
+		detailMessage := target + " " + message
+
+		if len(target) == 0 {
+			detailMessage = strings.ToUpper(message[:1]) + message[1:]
+		}
+
+		details = append(details, ErrorDetail{
+			Code:    detailCode,
+			Target:  target,
+			Message: detailMessage,
+		})
+	}
+
+	return details
+}
+
+// dbErrorColumns returns the constraint name and the columns from the driver error
+func dbErrorColumns(err error) (string, []string) {
+	message := err.Error()
+	constraint := dbErrorField(err, "ConstraintName", "Constraint")
+
+	if detail := dbErrorField(err, "Detail"); len(detail) > 0 {
+		message = message + " " + detail
+	}
+
+	if matches := postgresKeyPattern.FindStringSubmatch(message); len(matches) > 0 {
+		return constraint, splitColumns(matches[1], "")
+	}
+
+	if matches := mysqlForeignPattern.FindStringSubmatch(message); len(matches) > 0 {
+		return matches[1], splitColumns(matches[2], "`")
+	}
+
+	if matches := mysqlDuplicatePattern.FindStringSubmatch(message); len(matches) > 0 {
+		key := matches[2]
+
+		// MySQL 8 prefixes the key with the table name
+		if index := strings.LastIndex(key, "."); index >= 0 {
+			key = key[index+1:]
+		}
+
+		return key, nil
+	}
+
+	if matches := sqliteColumnsPattern.FindStringSubmatch(message); len(matches) > 0 {
+		columns := splitColumns(matches[1], "")
+
+		for i, column := range columns {
+			if index := strings.LastIndex(column, "."); index >= 0 {
+				columns[i] = column[index+1:]
+			}
+		}
+
+		return constraint, columns
+	}
+
+	return constraint, nil
+}
+
+func splitColumns(columns string, quote string) []string {
+	parts := strings.Split(columns, ",")
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(quote) > 0 {
+			part = strings.Trim(part, quote)
+		}
+		parts[i] = strings.Trim(part, `"`)
+	}
+
+	return parts
+}
+
+func dbErrorTarget(constraint string, column string, fieldMap map[string]string) string {
+	if field, ok := fieldMap[constraint]; ok && len(constraint) > 0 {
+		return field
+	}
+
+	if len(column) == 0 {
+		return ""
+	}
+
+	if field, ok := fieldMap[column]; ok {
+		return field
+	}
+
+	return strcase.ToLowerCamel(column)
+}
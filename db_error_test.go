@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// pgError has the fields of the pgconn.PgError of the postgres driver
+type pgError struct {
+	Code           string
+	Message        string
+	Detail         string
+	ConstraintName string
+}
+
+func (e *pgError) Error() string {
+	return "ERROR: " + e.Message + " (SQLSTATE " + e.Code + ")"
+}
+
+// mysqlError formats the error like the MySQLError of the mysql driver
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+func TestCheckDbError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "record not found", err: gorm.ErrRecordNotFound, code: NOT_FOUND},
+		{name: "wrapped record not found", err: fmt.Errorf("find: %w", gorm.ErrRecordNotFound), code: NOT_FOUND},
+		{name: "deadline", err: context.DeadlineExceeded, code: DEADLINE_EXCEEDED},
+		{name: "cancelled", err: context.Canceled, code: CANCELLED},
+		{name: "postgres unique", err: &pgError{Code: "23505"}, code: ALREADY_EXISTS},
+		{name: "postgres foreign key", err: &pgError{Code: "23503"}, code: REFERENCE_INTEGRITY_FAIL},
+		{name: "postgres not null", err: &pgError{Code: "23502"}, code: INVALID_ARGUMENT},
+		{name: "postgres serialization", err: &pgError{Code: "40001"}, code: ABORTED},
+		{name: "postgres message", err: errors.New("ERROR: canceling statement (SQLSTATE 57014)"), code: DEADLINE_EXCEEDED},
+		{name: "mysql duplicate", err: &mysqlError{Number: 1062}, code: ALREADY_EXISTS},
+		{name: "mysql foreign key", err: &mysqlError{Number: 1452}, code: REFERENCE_INTEGRITY_FAIL},
+		{name: "mysql deadlock", err: &mysqlError{Number: 1213}, code: ABORTED},
+		{name: "wrapped mysql", err: fmt.Errorf("create: %w", &mysqlError{Number: 1048}), code: INVALID_ARGUMENT},
+		{name: "sqlite unique", err: errors.New("UNIQUE constraint failed: users.email"), code: ALREADY_EXISTS},
+		{name: "sqlite foreign key", err: errors.New("FOREIGN KEY constraint failed"), code: REFERENCE_INTEGRITY_FAIL},
+		{name: "sqlite locked", err: errors.New("database is locked"), code: ABORTED},
+		{name: "sqlite interrupted", err: errors.New("interrupted"), code: DEADLINE_EXCEEDED},
+		{name: "wrapped sqlite unique", err: fmt.Errorf("create: %w", errors.New("UNIQUE constraint failed: users.email")), code: ALREADY_EXISTS},
+		{name: "system call interrupted", err: errors.New("read: system call interrupted"), code: INTERNAL_SERVER_ERROR},
+		{name: "message mentioning a constraint", err: errors.New("validate: NOT NULL constraint failed"), code: INTERNAL_SERVER_ERROR},
+		{name: "unknown", err: errors.New("connection refused"), code: INTERNAL_SERVER_ERROR},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := CheckDbError(test.err); code != test.code {
+				t.Errorf("code = %s, want %s", code, test.code)
+			}
+		})
+	}
+}
+
+func TestDbErrorDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fieldMap map[string]string
+		targets  []string
+	}{
+		{
+			name:    "postgres key columns",
+			err:     &pgError{Code: "23505", Detail: "Key (tenant_id, email)=(1, a@b.c) already exists.", ConstraintName: "idx_users_email"},
+			targets: []string{"tenantId", "email"},
+		},
+		{
+			name:     "postgres mapped constraint",
+			err:      &pgError{Code: "23505", Detail: "Key (lower(email))=(a@b.c) already exists.", ConstraintName: "idx_users_email"},
+			fieldMap: map[string]string{"idx_users_email": "email"},
+			targets:  []string{"email"},
+		},
+		{
+			name:    "mysql duplicate index without field map",
+			err:     &mysqlError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.idx_users_email'"},
+			targets: []string{""},
+		},
+		{
+			name:     "mysql mapped index",
+			err:      &mysqlError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.idx_users_email'"},
+			fieldMap: map[string]string{"idx_users_email": "email"},
+			targets:  []string{"email"},
+		},
+		{
+			name:    "mysql foreign key",
+			err:     &mysqlError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`orders`, CONSTRAINT `fk_orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"},
+			targets: []string{"userId"},
+		},
+		{
+			name:    "sqlite columns",
+			err:     errors.New("UNIQUE constraint failed: users.email"),
+			targets: []string{"email"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets := make([]string, 0)
+			for _, detail := range DbErrorDetails(test.err, test.fieldMap) {
+				targets = append(targets, detail.Target)
+			}
+
+			if !reflect.DeepEqual(targets, test.targets) {
+				t.Errorf("targets = %q, want %q", targets, test.targets)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package common
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeCSV     = "text/csv"
+	MediaTypeMsgPack = "application/x-msgpack"
+)
+
+// Encoder - Encode the api response for a media type
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, key string, response interface{}) error
+}
+
+type encoderEntry struct {
+	mediaType string
+	encoder   Encoder
+}
+
+var (
+	encoders       = []encoderEntry{}
+	encoderFormats = map[string]string{}
+)
+
+func init() {
+	RegisterEncoder(MediaTypeJSON, JSONEncoder{}, "json")
+	RegisterEncoder(ProblemContentType, JSONEncoder{})
+	RegisterEncoder(MediaTypeXML, XMLEncoder{}, "xml")
+	RegisterEncoder("text/xml", XMLEncoder{})
+	RegisterEncoder(MediaTypeCSV, CSVEncoder{}, "csv")
+	RegisterEncoder(MediaTypeMsgPack, MsgPackEncoder{}, "msgpack")
+	RegisterEncoder("application/msgpack", MsgPackEncoder{})
+}
+
+// RegisterEncoder - Register the encoder for the media type and the ?format= aliases.
+// The first registered encoder is used when the client accepts any media type.
+func RegisterEncoder(mediaType string, encoder Encoder, formats ...string) {
+	mediaType = strings.ToLower(mediaType)
+
+	for i, entry := range encoders {
+		if entry.mediaType == mediaType {
+			encoders = append(encoders[:i], encoders[i+1:]...)
+			break
+		}
+	}
+
+	encoders = append(encoders, encoderEntry{mediaType: mediaType, encoder: encoder})
+
+	for _, format := range formats {
+		encoderFormats[strings.ToLower(format)] = mediaType
+	}
+}
+
+func findEncoder(mediaType string) Encoder {
+	for _, entry := range encoders {
+		if entry.mediaType == mediaType {
+			return entry.encoder
+		}
+	}
+
+	return nil
+}
+
+// NegotiateEncoder - Select the encoder from the format query or the Accept header. The media
+// ranges of the highest quality win and equal qualities prefer the first registered encoder
+// (JSON). A client accepting */* gets JSON when it prefers media types that can not be served,
+// like the text/html of the browsers which accept application/xml with a lower quality.
+func NegotiateEncoder(c *gin.Context) (Encoder, bool) {
+	if format := c.Query("format"); len(format) > 0 {
+		mediaType, ok := encoderFormats[strings.ToLower(format)]
+		if !ok {
+			return nil, false
+		}
+
+		return findEncoder(mediaType), true
+	}
+
+	accept := c.Request.Header.Get("Accept")
+
+	if len(strings.TrimSpace(accept)) == 0 {
+		return encoders[0].encoder, true
+	}
+
+	ranges := parseAccept(accept)
+	anyType := false
+
+	for _, mediaRange := range ranges {
+		if mediaRange.value == "*/*" {
+			anyType = true
+		}
+	}
+
+	for start := 0; start < len(ranges); {
+		end := start
+		for end < len(ranges) && ranges[end].quality == ranges[start].quality {
+			end++
+		}
+
+		for _, entry := range encoders {
+			for _, mediaRange := range ranges[start:end] {
+				if mediaRange.matches(entry.mediaType) {
+					return entry.encoder, true
+				}
+			}
+		}
+
+		// the preferred media types can not be served
+		if anyType {
+			return encoders[0].encoder, true
+		}
+
+		start = end
+	}
+
+	return nil, false
+}
+
+type acceptRange struct {
+	value   string
+	quality float64
+}
+
+func (r acceptRange) matches(mediaType string) bool {
+	if r.value == "*/*" || r.value == mediaType {
+		return true
+	}
+
+	return strings.HasSuffix(r.value, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(r.value, "*"))
+}
+
+// parseAccept returns the accepted media ranges ordered by quality
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				quality, _ = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			}
+		}
+
+		if len(value) > 0 && quality > 0 {
+			ranges = append(ranges, acceptRange{value: value, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	return ranges
+}
+
+// renderSuccess writes the response with the negotiated encoder
+func renderSuccess(c *gin.Context, status int, key string, response interface{}) {
+	encoder, ok := NegotiateEncoder(c)
+
+	if !ok {
+		NotAcceptable(c, "")
+		return
+	}
+
+	var buf bytes.Buffer
+
+	response = withFields(c, key, response)
+
+	if err := encoder.Encode(&buf, key, response); err != nil {
+		InternalServerError(c, "")
+		return
+	}
+
+	if links, ok := c.Get("pageLinks"); ok {
+		if pageLinks, ok := links.(PageLinks); ok {
+			if header := LinkHeader(pageLinks); len(header) > 0 {
+				c.Header("Link", header)
+			}
+		}
+	}
+
+	bodyTag := func() string {
+		var tagBuf bytes.Buffer
+
+		if err := encoder.Encode(&tagBuf, key, withoutRequestID(response)); err != nil {
+			return ""
+		}
+
+		return bodyEntityTag(encoder.ContentType(), tagBuf.Bytes())
+	}
+
+	if notModified(c, status, bodyTag) {
+		return
+	}
+
+	c.Data(status, encoder.ContentType(), buf.Bytes())
+}
+
+// toGeneric converts the value to plain maps, slices and json numbers
+func toGeneric(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// JSONEncoder - Encode the response as JSON
+type JSONEncoder struct{}
+
+func (e JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (e JSONEncoder) Encode(w io.Writer, key string, response interface{}) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// XMLEncoder - Encode the response as XML with a <response> root element
+type XMLEncoder struct{}
+
+func (e XMLEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (e XMLEncoder) Encode(w io.Writer, key string, response interface{}) error {
+	generic, err := toGeneric(response)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	if err := encodeXMLElement(encoder, "response", generic); err != nil {
+		return err
+	}
+
+	return encoder.Flush()
+}
+
+func encodeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			if err := encodeXMLElement(encoder, key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := encodeXMLElement(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// CSVEncoder - Encode the data under the response key as CSV rows
+type CSVEncoder struct{}
+
+func (e CSVEncoder) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e CSVEncoder) Encode(w io.Writer, key string, response interface{}) error {
+	generic, err := toGeneric(response)
+	if err != nil {
+		return err
+	}
+
+	var rows []interface{}
+
+	if body, ok := generic.(map[string]interface{}); ok {
+		if data, ok := body["data"].(map[string]interface{}); ok {
+			switch v := data[key].(type) {
+			case []interface{}:
+				rows = v
+			case nil:
+			default:
+				rows = []interface{}{v}
+			}
+		}
+	}
+
+	records := make([]map[string]string, 0, len(rows))
+	columnSet := map[string]interface{}{}
+
+	for _, row := range rows {
+		record := map[string]string{}
+		flattenCSV("", row, record)
+
+		for column := range record {
+			columnSet[column] = true
+		}
+		records = append(records, record)
+	}
+
+	columns := sortedKeys(columnSet)
+	writer := csv.NewWriter(w)
+
+	if len(columns) > 0 {
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			line[i] = record[column]
+		}
+
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// flattenCSV flattens nested objects into dot separated columns
+func flattenCSV(prefix string, value interface{}, record map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			column := key
+			if len(prefix) > 0 {
+				column = prefix + "." + key
+			}
+			flattenCSV(column, item, record)
+		}
+	case []interface{}:
+		data, _ := json.Marshal(v)
+		record[csvColumn(prefix)] = string(data)
+	case nil:
+		record[csvColumn(prefix)] = ""
+	default:
+		record[csvColumn(prefix)] = fmt.Sprint(v)
+	}
+}
+
+func csvColumn(prefix string) string {
+	if len(prefix) == 0 {
+		return "value"
+	}
+
+	return prefix
+}
+
+// MsgPackEncoder - Encode the response as MessagePack
+type MsgPackEncoder struct{}
+
+func (e MsgPackEncoder) ContentType() string {
+	return MediaTypeMsgPack
+}
+
+func (e MsgPackEncoder) Encode(w io.Writer, key string, response interface{}) error {
+	var handle codec.MsgpackHandle
+
+	return codec.NewEncoder(w, &handle).Encode(response)
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoder(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		accept      string
+		contentType string
+	}{
+		{name: "no accept", url: "/", contentType: "application/json"},
+		{name: "any type", url: "/", accept: "*/*", contentType: "application/json"},
+		{
+			name:        "browser",
+			url:         "/",
+			accept:      "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			contentType: "application/json",
+		},
+		{name: "xml", url: "/", accept: "application/xml", contentType: "application/xml"},
+		{name: "xml with fallback", url: "/", accept: "application/xml, */*;q=0.1", contentType: "application/xml"},
+		{name: "equal quality", url: "/", accept: "application/xml, application/json", contentType: "application/json"},
+		{name: "xml preferred", url: "/", accept: "application/json;q=0.5, application/xml", contentType: "application/xml"},
+		{name: "format query", url: "/?format=csv", accept: "application/json", contentType: "text/csv"},
+		{name: "not acceptable", url: "/", accept: "image/png", contentType: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, test.url, nil)
+
+			if len(test.accept) > 0 {
+				c.Request.Header.Set("Accept", test.accept)
+			}
+
+			encoder, ok := NegotiateEncoder(c)
+			contentType := ""
+			if ok {
+				contentType = encoder.ContentType()
+			}
+
+			if !strings.HasPrefix(contentType, test.contentType) || (len(test.contentType) == 0) != !ok {
+				t.Errorf("content type = %q, want %q", contentType, test.contentType)
+			}
+		})
+	}
+}
+
+func TestPageLinkHeader(t *testing.T) {
+	router := gin.New()
+	router.GET("/items", func(c *gin.Context) {
+		pagination := Paginator(c)
+		SuccessPageResponse(c, "items", []int{1, 2}, PageInfo(pagination, 100))
+	})
+
+	tests := []struct {
+		accept string
+		status int
+		link   bool
+	}{
+		{accept: "application/json", status: http.StatusOK, link: true},
+		{accept: "image/png", status: http.StatusNotAcceptable, link: false},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/items?pageSize=2", nil)
+		request.Header.Set("Accept", test.accept)
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.accept, recorder.Code, test.status)
+		}
+
+		if link := recorder.Header().Get("Link"); (len(link) > 0) != test.link {
+			t.Errorf("%s: Link = %q, want link %v", test.accept, link, test.link)
+		}
+	}
+}
+
+func TestPageLinkForwardedHost(t *testing.T) {
+	router := gin.New()
+	router.GET("/items", func(c *gin.Context) {
+		pagination := Paginator(c)
+		SuccessPageResponse(c, "items", []int{1, 2}, PageInfo(pagination, 100))
+	})
+
+	tests := []struct {
+		name    string
+		proxies []string
+		prefix  string
+	}{
+		{name: "untrusted peer", proxies: nil, prefix: "<http://example.com/items?"},
+		{name: "other proxy", proxies: []string{"10.0.0.0/8"}, prefix: "<http://example.com/items?"},
+		{name: "trusted proxy", proxies: []string{"192.0.2.0/24"}, prefix: "<https://api.example/items?"},
+	}
+
+	defer SetTrustedProxies()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetTrustedProxies(test.proxies...)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/items?pageSize=2", nil)
+			request.Header.Set("X-Forwarded-Host", "api.example")
+			request.Header.Set("X-Forwarded-Proto", "https")
+			router.ServeHTTP(recorder, request)
+
+			if link := recorder.Header().Get("Link"); !strings.HasPrefix(link, test.prefix) {
+				t.Errorf("Link = %q, want prefix %q", link, test.prefix)
+			}
+		})
+	}
+}
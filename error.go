@@ -1,7 +1,5 @@
 package common
 
-import "strings"
-
 const (
 	SUCCESS        = 200
 	ERROR          = 500
@@ -35,6 +33,8 @@ const (
 	UNAVAILABLE              = "UNAVAILABLE"
 	DEADLINE_EXCEEDED        = "DEADLINE_EXCEEDED"
 	REFERENCE_INTEGRITY_FAIL = "REFERENCE_INTEGRITY_FAIL"
+	NOT_ACCEPTABLE           = "NOT_ACCEPTABLE"
+	FAILED_PRECONDITION      = "FAILED_PRECONDITION"
 )
 
 type ErrorData struct {
@@ -48,18 +48,3 @@ type ErrorDetail struct {
 	Target  string `json:"target" example:"Name"`
 	Message string `json:"message" example:"Name field is required"`
 }
-
-func CheckDbError(err error) string {
-	if strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
-		return ALREADY_EXISTS
-	}
-	if strings.HasPrefix(err.Error(), "Error 1452: Cannot add or update a child") {
-		return REFERENCE_INTEGRITY_FAIL
-	}
-
-	if strings.HasPrefix(err.Error(), "record not found") {
-		return NOT_FOUND
-	}
-
-	return INTERNAL_SERVER_ERROR
-}
@@ -0,0 +1,237 @@
+package common
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+const (
+	ExportNDJSON = "ndjson"
+	ExportCSV    = "csv"
+)
+
+// ExportConfig - Format, column projection and batch size of a streaming export
+type ExportConfig struct {
+	//Format is ndjson or csv, negotiated from ?format= and Accept when empty
+	Format string
+	//Columns projects the query to the columns, all the columns are exported when empty
+	Columns []string
+	//BatchSize is the number of rows written between flushes, 500 by default
+	BatchSize int
+	//FileName sets the Content-Disposition attachment file name
+	FileName string
+}
+
+// StreamExport - Stream all the rows of the query as NDJSON or CSV with chunked transfer
+// encoding. db carries the same filter, search and order scopes as the paged API but no
+// pagination. The errors are responded before the export starts and logged once the rows
+// are streamed. The export stops when the client disconnects.
+func StreamExport(c *gin.Context, db *gorm.DB, config ExportConfig) {
+	format := exportFormat(c, config.Format)
+
+	if len(format) == 0 {
+		NotAcceptable(c, "Export format is not supported")
+		return
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	// the query is cancelled with the request when the client disconnects
+	tx := db.WithContext(c.Request.Context())
+
+	if len(config.Columns) > 0 {
+		tx = tx.Select(config.Columns)
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = strcase.ToLowerCamel(column)
+	}
+
+	c.Header("Content-Type", exportContentType(format))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if len(config.FileName) > 0 {
+		c.Header("Content-Disposition", `attachment; filename="`+config.FileName+`"`)
+	}
+
+	c.Status(http.StatusOK)
+
+	writer := bufio.NewWriter(c.Writer)
+	csvWriter := csv.NewWriter(writer)
+	encoder := json.NewEncoder(writer)
+
+	if format == ExportCSV {
+		if err := csvWriter.Write(fields); err != nil {
+			exportError(c, err)
+			return
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	count := 0
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			exportError(c, err)
+			return
+		}
+
+		if format == ExportCSV {
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = exportText(value)
+			}
+			if err := csvWriter.Write(record); err != nil {
+				exportError(c, err)
+				return
+			}
+		} else {
+			row := make(map[string]interface{}, len(values))
+			for i, value := range values {
+				row[fields[i]] = exportValue(value)
+			}
+			if err := encoder.Encode(row); err != nil {
+				exportError(c, err)
+				return
+			}
+		}
+
+		count++
+
+		if count%batchSize == 0 {
+			if err := exportFlush(c, writer, csvWriter); err != nil {
+				exportError(c, err)
+				return
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		exportError(c, err)
+		return
+	}
+
+	if err := exportFlush(c, writer, csvWriter); err != nil {
+		exportError(c, err)
+	}
+}
+
+// exportFormat returns the requested export format or empty when not supported
+func exportFormat(c *gin.Context, format string) string {
+	if len(format) == 0 {
+		format = c.Query("format")
+	}
+
+	if len(format) == 0 {
+		accept := c.GetHeader("Accept")
+
+		switch {
+		case strings.Contains(accept, "text/csv"):
+			format = ExportCSV
+		case len(accept) == 0 || strings.Contains(accept, "ndjson") || strings.Contains(accept, "*/*"):
+			format = ExportNDJSON
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case ExportCSV:
+		return ExportCSV
+	case ExportNDJSON, "jsonl":
+		return ExportNDJSON
+	}
+
+	return ""
+}
+
+func exportContentType(format string) string {
+	if format == ExportCSV {
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/x-ndjson"
+}
+
+// exportFlush writes the buffered batch to the client
+func exportFlush(c *gin.Context, writer *bufio.Writer, csvWriter *csv.Writer) error {
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	c.Writer.Flush()
+
+	return c.Request.Context().Err()
+}
+
+// exportError logs the error of a started export, the status can not be changed anymore
+func exportError(c *gin.Context, err error) {
+	if log, ok := c.Get("log"); ok {
+		if microLog, ok := log.(*MicroLog); ok {
+			microLog.Logger().Warn("Export stopped: " + err.Error())
+		}
+	}
+}
+
+// exportValue converts the driver value of the column to a JSON value
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+
+	return value
+}
+
+// exportText converts the driver value of the column to a CSV cell
+func exportText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(value)
+}
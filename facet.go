@@ -0,0 +1,130 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+// FacetValue - Count of the rows having the facet value
+type FacetValue struct {
+	Value    interface{} `json:"value"`
+	Count    int64       `json:"count"`
+	Selected bool        `json:"selected"`
+}
+
+// FilterResult - Applied filters and facet counts of the search output to API under _filters
+type FilterResult struct {
+	Applied map[string]map[string]interface{} `json:"applied"`
+	Facets  map[string][]FacetValue           `json:"facets,omitempty"`
+}
+
+// FacetCounts - Count the rows of each facet value grouped by the facet fields.
+// db is the unpaginated query without the filters, every facet is counted with all
+// the filters applied except the filters on the facet field itself.
+func FacetCounts(db *gorm.DB, filters []Filter, facets []string) (map[string][]FacetValue, error) {
+	result := map[string][]FacetValue{}
+
+	for _, facet := range facets {
+		column := strcase.ToSnake(facet)
+		others := make([]Filter, 0, len(filters))
+		selected := make([]Filter, 0)
+
+		for _, filter := range filters {
+			if filter.Field == facet || filter.Column == column {
+				selected = append(selected, filter)
+				continue
+			}
+			others = append(others, filter)
+		}
+
+		rows, err := db.Session(&gorm.Session{}).
+			Scopes(FilterScope(others)).
+			Select(column + " AS value, COUNT(*) AS count").
+			Group(column).
+			Rows()
+
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]FacetValue, 0)
+
+		for rows.Next() {
+			var facetValue FacetValue
+
+			if err := rows.Scan(&facetValue.Value, &facetValue.Count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			if bytes, ok := facetValue.Value.([]byte); ok {
+				facetValue.Value = string(bytes)
+			}
+
+			facetValue.Selected = facetSelected(facetValue.Value, selected)
+			values = append(values, facetValue)
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		sort.SliceStable(values, func(i, j int) bool {
+			if values[i].Count == values[j].Count {
+				return compareSliceValues(values[i].Value, values[j].Value) < 0
+			}
+			return values[i].Count > values[j].Count
+		})
+
+		result[facet] = values
+	}
+
+	return result, nil
+}
+
+// FacetFilterInfo - Gives the applied filters with the facet counts to API
+func FacetFilterInfo(filters []Filter, facets map[string][]FacetValue) FilterResult {
+	return FilterResult{
+		Applied: FilterInfo(filters),
+		Facets:  facets,
+	}
+}
+
+// facetSelected tells whether the eq or in filters of the facet select the value
+func facetSelected(value interface{}, filters []Filter) bool {
+	if value == nil {
+		for _, filter := range filters {
+			if filter.Operator == FilterIsNull && filter.Value == true {
+				return true
+			}
+		}
+		return false
+	}
+
+	text := fmt.Sprint(value)
+
+	for _, filter := range filters {
+		switch filter.Operator {
+		case FilterEq:
+			if fmt.Sprint(filter.Value) == text {
+				return true
+			}
+		case FilterIn:
+			if items, ok := filter.Value.([]interface{}); ok {
+				for _, item := range items {
+					if fmt.Sprint(item) == text {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+// ParseFields - Parse the ?fields=id,name,category.name query param validated against the
+// allowed fields. A nested field is allowed when the field or its parent is allowed.
+// The fields are kept in the context so that the success responses render only these fields.
+func ParseFields(c *gin.Context, allowedFields []string) ([]string, *ErrorData) {
+	fields := make([]string, 0)
+	errorDetails := make([]ErrorDetail, 0)
+	seen := map[string]bool{}
+
+	allowed := map[string]bool{}
+	for _, field := range allowedFields {
+		allowed[field] = true
+	}
+
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		field = strings.TrimSpace(field)
+
+		if len(field) == 0 || seen[field] {
+			continue
+		}
+
+		seen[field] = true
+
+		if !fieldAllowed(field, allowed) {
+			errorDetails = append(errorDetails, ErrorDetail{
+				Code:    "NotAllowed",
+				Target:  field,
+				Message: "Field " + field + " is not allowed",
+			})
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	if len(errorDetails) > 0 {
+		return nil, &ErrorData{
+			Code:    INVALID_ARGUMENT,
+			Message: "Invalid fields",
+			Details: errorDetails,
+		}
+	}
+
+	if len(fields) > 0 {
+		c.Set("fields", fields)
+	}
+
+	return fields, nil
+}
+
+func fieldAllowed(field string, allowed map[string]bool) bool {
+	path := strings.Split(field, ".")
+
+	for i := range path {
+		if allowed[strings.Join(path[:i+1], ".")] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FieldsScope - Select only the columns of the requested fields. columns maps a field to
+// its column, "-" skips the fields which are not columns like associations. Nested fields
+// like category.name are skipped unless the field or its parent is mapped. The required
+// columns like the primary and foreign keys of preloaded associations are always selected.
+func FieldsScope(fields []string, columns map[string]string, required ...string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(fields) == 0 {
+			return db
+		}
+
+		selected := make([]string, 0, len(fields)+len(required))
+		seen := map[string]bool{}
+
+		add := func(column string) {
+			if len(column) > 0 && column != "-" && !seen[column] {
+				seen[column] = true
+				selected = append(selected, column)
+			}
+		}
+
+		for _, field := range fields {
+			if column, ok := columns[field]; ok {
+				add(column)
+				continue
+			}
+
+			// a nested field belongs to an association, its column must be mapped explicitly
+			if strings.Contains(field, ".") {
+				if column, ok := columns[strings.Split(field, ".")[0]]; ok {
+					add(column)
+				}
+				continue
+			}
+
+			add(strcase.ToSnake(field))
+		}
+
+		for _, column := range required {
+			add(column)
+		}
+
+		if len(selected) == 0 {
+			return db
+		}
+
+		return db.Select(selected)
+	}
+}
+
+// withFields renders only the requested fields of the data under the key
+func withFields(c *gin.Context, key string, response interface{}) interface{} {
+	value, ok := c.Get("fields")
+	if !ok {
+		return response
+	}
+
+	fields, ok := value.([]string)
+	if !ok || len(fields) == 0 {
+		return response
+	}
+
+	generic, err := toGeneric(response)
+	if err != nil {
+		return response
+	}
+
+	body, ok := generic.(map[string]interface{})
+	if !ok {
+		return response
+	}
+
+	data, ok := body["data"].(map[string]interface{})
+	if !ok {
+		return response
+	}
+
+	if payload, ok := data[key]; ok {
+		data[key] = pruneFields(payload, fieldTree(fields))
+	}
+
+	return plainNumbers(body)
+}
+
+// plainNumbers converts the json numbers back to int64 or float64 so that the other encoders
+// render the same types as for the unpruned response
+func plainNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = plainNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+
+		if number, err := v.Float64(); err == nil {
+			return number
+		}
+
+		return v.String()
+	}
+
+	return value
+}
+
+// fieldTree converts a.b paths to nested maps, nil keeps the whole value
+func fieldTree(fields []string) map[string]interface{} {
+	tree := map[string]interface{}{}
+
+	for _, field := range fields {
+		node := tree
+		path := strings.Split(field, ".")
+
+		for i, name := range path {
+			child, exists := node[name]
+
+			if exists && child == nil {
+				break
+			}
+
+			if i == len(path)-1 {
+				node[name] = nil
+				break
+			}
+
+			next, ok := child.(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				node[name] = next
+			}
+
+			node = next
+		}
+	}
+
+	return tree
+}
+
+// pruneFields keeps the fields of the tree in the objects of the value
+func pruneFields(value interface{}, tree map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = pruneFields(item, tree)
+		}
+		return items
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for name, child := range tree {
+			fieldValue, ok := v[name]
+			if !ok {
+				continue
+			}
+
+			if subTree, ok := child.(map[string]interface{}); ok {
+				object[name] = pruneFields(fieldValue, subTree)
+				continue
+			}
+
+			object[name] = fieldValue
+		}
+		return object
+	}
+
+	return value
+}
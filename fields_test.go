@@ -0,0 +1,83 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+func TestFieldsKeepNumberTypes(t *testing.T) {
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		ParseFields(c, []string{"id", "price"})
+		SuccessResponse(c, "items", []map[string]interface{}{{"id": 1, "price": 2.5, "name": "a"}})
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?fields=id,price&format=msgpack", nil))
+
+	var response map[string]interface{}
+	if err := codec.NewDecoderBytes(recorder.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := response["status"].(int64); !ok {
+		t.Errorf("status = %#v, want int64", response["status"])
+	}
+
+	data := response["data"].(map[interface{}]interface{})
+	item := data["items"].([]interface{})[0].(map[interface{}]interface{})
+
+	if item["id"] != int64(1) || item["price"] != 2.5 {
+		t.Errorf("item = %#v, want id 1 and price 2.5", item)
+	}
+
+	if _, ok := item["name"]; ok {
+		t.Errorf("item = %#v, name is not requested", item)
+	}
+}
+
+func TestFieldsScope(t *testing.T) {
+	type Product struct {
+		ID         uint
+		Name       string
+		CategoryID uint
+	}
+
+	tests := []struct {
+		name    string
+		fields  []string
+		columns map[string]string
+		sql     string
+	}{
+		{
+			name:   "fields to snake case columns",
+			fields: []string{"id", "categoryId"},
+			sql:    "SELECT `id`,`category_id` FROM `products`",
+		},
+		{
+			name:   "nested field is skipped",
+			fields: []string{"name", "category.name"},
+			sql:    "SELECT `name`,`id` FROM `products`",
+		},
+		{
+			name:    "nested field of a mapped parent",
+			fields:  []string{"name", "category.name"},
+			columns: map[string]string{"category": "category_id"},
+			sql:     "SELECT `name`,`category_id`,`id` FROM `products`",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt := dryRunDB(t).Scopes(FieldsScope(test.fields, test.columns, "id")).Find(&[]Product{}).Statement
+
+			if sql := stmt.SQL.String(); sql != test.sql {
+				t.Errorf("sql = %s, want %s", sql, test.sql)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+)
+
+const (
+	FilterEq     = "eq"
+	FilterNe     = "ne"
+	FilterIn     = "in"
+	FilterNin    = "nin"
+	FilterGt     = "gt"
+	FilterGte    = "gte"
+	FilterLt     = "lt"
+	FilterLte    = "lte"
+	FilterLike   = "like"
+	FilterIsNull = "isnull"
+)
+
+var filterOperators = map[string]string{
+	FilterEq:  "=",
+	FilterNe:  "<>",
+	FilterGt:  ">",
+	FilterGte: ">=",
+	FilterLt:  "<",
+	FilterLte: "<=",
+}
+
+var filterQueryPattern = regexp.MustCompile(`^filter\[([^\]]+)\](?:\[([^\]]+)\])?$`)
+
+// Filter - Valid filter condition created from the filter request
+type Filter struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+	Column   string      `json:"-"`
+}
+
+// ParseFilters - Parse filter[field][operator]=value query params validated against the
+// allowed fields and their operators. Empty operator list allows all the operators.
+func ParseFilters(c *gin.Context, allowedFilters map[string][]string) ([]Filter, *ErrorData) {
+	return BuildFilters(filterQuery(c), allowedFilters)
+}
+
+// filterQuery collects the filter query params as field -> operator -> value
+func filterQuery(c *gin.Context) map[string]map[string]interface{} {
+	raw := map[string]map[string]interface{}{}
+
+	for key, values := range c.Request.URL.Query() {
+		matches := filterQueryPattern.FindStringSubmatch(key)
+		if len(matches) == 0 || len(values) == 0 {
+			continue
+		}
+
+		operator := matches[2]
+		if len(operator) == 0 {
+			operator = FilterEq
+		}
+
+		if _, ok := raw[matches[1]]; !ok {
+			raw[matches[1]] = map[string]interface{}{}
+		}
+
+		raw[matches[1]][operator] = values[0]
+	}
+
+	return raw
+}
+
+// BuildFilters - Create the valid filters from field -> operator -> value
+func BuildFilters(raw map[string]map[string]interface{}, allowedFilters map[string][]string) ([]Filter, *ErrorData) {
+	filters := make([]Filter, 0)
+	errorDetails := make([]ErrorDetail, 0)
+
+	for field, operators := range raw {
+		allowedOperators, ok := allowedFilters[field]
+
+		if !ok {
+			errorDetails = append(errorDetails, ErrorDetail{
+				Code:    "NotAllowed",
+				Target:  field,
+				Message: "Filter is not allowed on " + field,
+			})
+			continue
+		}
+
+		for operator, value := range operators {
+			operator = strings.ToLower(operator)
+
+			if !validFilterOperator(operator, allowedOperators) {
+				errorDetails = append(errorDetails, ErrorDetail{
+					Code:    "Operator",
+					Target:  field,
+					Message: "Operator " + operator + " is not allowed on " + field,
+				})
+				continue
+			}
+
+			filterValue, ok := normalizeFilterValue(operator, value)
+			if !ok {
+				errorDetails = append(errorDetails, ErrorDetail{
+					Code:    "Invalid",
+					Target:  field,
+					Message: "Invalid value for " + field + " " + operator,
+				})
+				continue
+			}
+
+			filters = append(filters, Filter{
+				Field:    field,
+				Operator: operator,
+				Value:    filterValue,
+				Column:   strcase.ToSnake(field),
+			})
+		}
+	}
+
+	if len(errorDetails) > 0 {
+		sort.SliceStable(errorDetails, func(i, j int) bool {
+			return errorDetails[i].Target < errorDetails[j].Target
+		})
+
+		return nil, &ErrorData{
+			Code:    INVALID_ARGUMENT,
+			Message: "Invalid filter",
+			Details: errorDetails,
+		}
+	}
+
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Field == filters[j].Field {
+			return filters[i].Operator < filters[j].Operator
+		}
+		return filters[i].Field < filters[j].Field
+	})
+
+	return filters, nil
+}
+
+func validFilterOperator(operator string, allowedOperators []string) bool {
+	if _, ok := filterOperators[operator]; !ok {
+		switch operator {
+		case FilterIn, FilterNin, FilterLike, FilterIsNull:
+		default:
+			return false
+		}
+	}
+
+	if len(allowedOperators) == 0 {
+		return true
+	}
+
+	for _, allowed := range allowedOperators {
+		if allowed == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeFilterValue converts the query or body value to the value of the operator
+func normalizeFilterValue(operator string, value interface{}) (interface{}, bool) {
+	switch operator {
+	case FilterIn, FilterNin:
+		switch v := value.(type) {
+		case string:
+			values := make([]interface{}, 0)
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					values = append(values, item)
+				}
+			}
+			return values, len(values) > 0
+		case []interface{}:
+			return v, len(v) > 0
+		}
+		return nil, false
+	case FilterIsNull:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			isNull, err := strconv.ParseBool(v)
+			return isNull, err == nil
+		}
+		return nil, false
+	case FilterLike:
+		v, ok := value.(string)
+		return v, ok && len(v) > 0
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return nil, false
+	}
+
+	return value, true
+}
+
+// FilterScope - Do the db filter query
+func FilterScope(filters []Filter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, filter := range filters {
+			db = applyFilter(db, filter)
+		}
+
+		return db
+	}
+}
+
+func applyFilter(db *gorm.DB, filter Filter) *gorm.DB {
+	column := filter.Column
+
+	switch filter.Operator {
+	case FilterIn:
+		return db.Where(column+" IN ?", filter.Value)
+	case FilterNin:
+		return db.Where(column+" NOT IN ?", filter.Value)
+	case FilterIsNull:
+		if filter.Value == true {
+			return db.Where(column + " IS NULL")
+		}
+		return db.Where(column + " IS NOT NULL")
+	case FilterLike:
+		term := "%" + escapeLike(strings.ToLower(fmt.Sprint(filter.Value))) + "%"
+		return db.Where("LOWER("+column+") LIKE ? ESCAPE '!'", term)
+	}
+
+	return db.Where(column+" "+filterOperators[filter.Operator]+" ?", filter.Value)
+}
+
+// escapeLike escapes the LIKE wildcards with the '!' escape character
+// which is understood by MySQL, Postgres and SQLite alike
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+	return replacer.Replace(value)
+}
+
+// FilterInfo - Gives the applied filters to API as field -> operator -> value
+func FilterInfo(filters []Filter) map[string]map[string]interface{} {
+	info := map[string]map[string]interface{}{}
+
+	for _, filter := range filters {
+		if _, ok := info[filter.Field]; !ok {
+			info[filter.Field] = map[string]interface{}{}
+		}
+
+		info[filter.Field][filter.Operator] = filter.Value
+	}
+
+	return info
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseFilters(t *testing.T) {
+	allowedFilters := map[string][]string{
+		"status":    {FilterEq, FilterIn},
+		"price":     {},
+		"createdAt": {FilterGte, FilterLte},
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		filters []Filter
+		targets []string
+	}{
+		{
+			name:    "equal shorthand",
+			query:   "filter[status]=active",
+			filters: []Filter{{Field: "status", Operator: FilterEq, Value: "active", Column: "status"}},
+		},
+		{
+			name:    "in list",
+			query:   "filter[status][in]=active,draft",
+			filters: []Filter{{Field: "status", Operator: FilterIn, Value: []interface{}{"active", "draft"}, Column: "status"}},
+		},
+		{
+			name:  "range on a camel case field",
+			query: "filter[createdAt][gte]=2021-01-01&filter[createdAt][lte]=2021-12-31",
+			filters: []Filter{
+				{Field: "createdAt", Operator: FilterGte, Value: "2021-01-01", Column: "created_at"},
+				{Field: "createdAt", Operator: FilterLte, Value: "2021-12-31", Column: "created_at"},
+			},
+		},
+		{
+			name:    "operator case",
+			query:   "filter[price][GT]=10",
+			filters: []Filter{{Field: "price", Operator: FilterGt, Value: "10", Column: "price"}},
+		},
+		{
+			name:    "is null",
+			query:   "filter[price][isnull]=true",
+			filters: []Filter{{Field: "price", Operator: FilterIsNull, Value: true, Column: "price"}},
+		},
+		{name: "unknown field", query: "filter[secret]=x", targets: []string{"secret"}},
+		{name: "operator not allowed", query: "filter[status][like]=act", targets: []string{"status"}},
+		{name: "unknown operator", query: "filter[price][between]=1", targets: []string{"price"}},
+		{name: "invalid is null", query: "filter[price][isnull]=maybe", targets: []string{"price"}},
+		{name: "empty in list", query: "filter[status][in]=,", targets: []string{"status"}},
+		{
+			name:    "all the errors",
+			query:   "filter[secret]=x&filter[status][like]=act",
+			targets: []string{"secret", "status"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+escapeQuery(test.query), nil)
+
+			filters, errorData := ParseFilters(c, allowedFilters)
+
+			if len(test.targets) > 0 {
+				if errorData == nil {
+					t.Fatalf("filters = %v, want error", filters)
+				}
+
+				targets := make([]string, len(errorData.Details))
+				for i, detail := range errorData.Details {
+					targets[i] = detail.Target
+				}
+
+				if errorData.Code != INVALID_ARGUMENT || !reflect.DeepEqual(targets, test.targets) {
+					t.Errorf("error = %s %v, want %s %v", errorData.Code, targets, INVALID_ARGUMENT, test.targets)
+				}
+				return
+			}
+
+			if errorData != nil {
+				t.Fatalf("error = %v", errorData)
+			}
+
+			if !reflect.DeepEqual(filters, test.filters) {
+				t.Errorf("filters = %#v, want %#v", filters, test.filters)
+			}
+		})
+	}
+}
+
+// escapeQuery escapes the brackets of the filter params
+func escapeQuery(query string) string {
+	values, _ := url.ParseQuery(query)
+
+	return values.Encode()
+}
+
+func TestFilterScope(t *testing.T) {
+	type Product struct {
+		ID     uint
+		Status string
+	}
+
+	filters := []Filter{
+		{Field: "name", Operator: FilterLike, Value: "50%_off", Column: "name"},
+		{Field: "price", Operator: FilterIsNull, Value: false, Column: "price"},
+		{Field: "price", Operator: FilterLt, Value: "10", Column: "price"},
+		{Field: "status", Operator: FilterNin, Value: []interface{}{"draft"}, Column: "status"},
+	}
+
+	stmt := dryRunDB(t).Scopes(FilterScope(filters)).Find(&[]Product{}).Statement
+
+	sql := "SELECT * FROM `products` WHERE LOWER(name) LIKE ? ESCAPE '!' AND price IS NOT NULL AND price < ? AND status NOT IN (?)"
+	if stmt.SQL.String() != sql {
+		t.Errorf("sql = %s, want %s", stmt.SQL.String(), sql)
+	}
+
+	vars := []interface{}{"%50!%!_off%", "10", "draft"}
+	if !reflect.DeepEqual(stmt.Vars, vars) {
+		t.Errorf("vars = %#v, want %#v", stmt.Vars, vars)
+	}
+}
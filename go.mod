@@ -13,6 +13,7 @@ require (
 	github.com/mailgun/mailgun-go/v4 v4.5.3
 	github.com/sirupsen/logrus v1.8.0
 	github.com/smartystreets/goconvey v1.6.4 // indirect
+	github.com/ugorji/go/codec v1.1.7
 	github.com/unknwon/com v1.0.1
 	github.com/xeipuuv/gojsonschema v1.2.0
 	gorm.io/gorm v1.20.12
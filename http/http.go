@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -85,8 +86,21 @@ func (h *Http) Invoke(log *common.MicroLog, methodType, rawUrl string, jsonForm
 
 	var queryParam param
 
+	//The deadline of the incoming request is the budget of the outbound call, the request
+	//cancellation is not passed on so that calls made after the response still complete
+	ctx := context.Background()
+	if log != nil && log.C != nil && log.C.Request != nil {
+		if deadline, ok := log.C.Request.Context().Deadline(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+	}
+
 	for _, v := range vs {
 		switch vv := v.(type) {
+		case context.Context:
+			ctx = vv
 		case http.Header:
 			for key, values := range vv {
 				for _, value := range values {
@@ -118,6 +132,7 @@ func (h *Http) Invoke(log *common.MicroLog, methodType, rawUrl string, jsonForm
 		return nil, err
 	}
 	req.URL = u
+	req = req.WithContext(ctx)
 
 	resp, err := client.Do(req)
 
@@ -144,6 +159,7 @@ func (h *Http) SetHeaders(header *http.Header, c *gin.Context) {
 	if c.Request.Header.Get("X-Auth-Type") == "vendor" {
 		header.Set("X-Reference-Id", c.Request.Header.Get("X-Reference-Id"))
 	}
+	header.Set("X-Request-Id", common.RequestID(c))
 	header.Set("X-B3-Traceid", c.Request.Header.Get("X-B3-Traceid"))
 	header.Set("X-B3-Spanid", c.Request.Header.Get("X-B3-Spanid"))
 	header.Set("X-Name", c.Request.Header.Get("X-Name"))
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvokeRequestContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ok   bool
+	}{
+		{name: "cancelled after the response", ctx: cancelled, ok: true},
+		{name: "request deadline passed", ctx: expired, ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(test.ctx)
+
+			log := common.New("panic", nil)
+			log.C = c
+
+			body, err := (&Http{}).Invoke(log, http.MethodGet, server.URL, nil)
+
+			if (err == nil) != test.ok {
+				t.Fatalf("err = %v, want ok %v", err, test.ok)
+			}
+
+			if test.ok && string(body) != "ok" {
+				t.Errorf("body = %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageLinks - Navigation links of the page output to API
+type PageLinks struct {
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// withPageLinks adds the navigation links to the page result and keeps them for the RFC 8288 Link header
+func withPageLinks(c *gin.Context, page interface{}) interface{} {
+	var links *PageLinks
+
+	switch result := page.(type) {
+	case PageResult:
+		if result.Links == nil {
+			result.Links = OffsetPageLinks(c, result)
+		}
+		links, page = result.Links, result
+	case *PageResult:
+		if result != nil {
+			if result.Links == nil {
+				result.Links = OffsetPageLinks(c, *result)
+			}
+			links = result.Links
+		}
+	case CursorPageResult:
+		if result.Links == nil {
+			result.Links = CursorPageLinks(c, result)
+		}
+		links, page = result.Links, result
+	case *CursorPageResult:
+		if result != nil {
+			if result.Links == nil {
+				result.Links = CursorPageLinks(c, *result)
+			}
+			links = result.Links
+		}
+	}
+
+	// the Link header is set by renderSuccess once the response is encoded
+	if links != nil {
+		c.Set("pageLinks", *links)
+	}
+
+	return page
+}
+
+// OffsetPageLinks - Create the first, prev, next and last links from the request url
+func OffsetPageLinks(c *gin.Context, pageResult PageResult) *PageLinks {
+	links := &PageLinks{}
+	lastPage := int(pageResult.TotalPages)
+
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	pageURL := func(number int) string {
+		return requestURL(c, func(query url.Values) {
+			query.Del("pageOffset")
+			query.Del("pageCursor")
+			query.Set("pageNumber", strconv.Itoa(number))
+			query.Set("pageSize", strconv.Itoa(pageResult.PageSize))
+		})
+	}
+
+	// the last page is unknown without a count or beyond a capped count
+	knownLast := pageResult.CountMode != CountNone && pageResult.CountMode != CountCapped
+
+	links.First = pageURL(1)
+
+	if knownLast {
+		links.Last = pageURL(lastPage)
+	}
+
+	if pageResult.PageNumber > 1 {
+		prev := pageResult.PageNumber - 1
+		if knownLast && prev > lastPage {
+			prev = lastPage
+		}
+		links.Prev = pageURL(prev)
+	}
+
+	if pageResult.HasMore {
+		links.Next = pageURL(pageResult.PageNumber + 1)
+	}
+
+	return links
+}
+
+// CursorPageLinks - Create the first, prev and next links from the request url
+func CursorPageLinks(c *gin.Context, pageResult CursorPageResult) *PageLinks {
+	links := &PageLinks{}
+
+	cursorURL := func(cursor string) string {
+		return requestURL(c, func(query url.Values) {
+			query.Del("pageNumber")
+			query.Del("pageOffset")
+			query.Del("pageCursor")
+			query.Set("pageSize", strconv.Itoa(pageResult.PageSize))
+
+			if len(cursor) > 0 {
+				query.Set("pageCursor", cursor)
+			}
+		})
+	}
+
+	links.First = cursorURL("")
+
+	if len(pageResult.PrevCursor) > 0 {
+		links.Prev = cursorURL(pageResult.PrevCursor)
+	}
+
+	if len(pageResult.NextCursor) > 0 {
+		links.Next = cursorURL(pageResult.NextCursor)
+	}
+
+	return links
+}
+
+// LinkHeader - Format the links as RFC 8288 Link header value
+func LinkHeader(links PageLinks) string {
+	values := make([]string, 0, 4)
+
+	for _, link := range []struct{ rel, url string }{
+		{"first", links.First},
+		{"prev", links.Prev},
+		{"next", links.Next},
+		{"last", links.Last},
+	} {
+		if len(link.url) > 0 {
+			values = append(values, "<"+link.url+`>; rel="`+link.rel+`"`)
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// requestURL returns the absolute url of the request with the modified query, honouring
+// the X-Forwarded-Proto and X-Forwarded-Host of the gateway when it is a trusted proxy
+func requestURL(c *gin.Context, modify func(query url.Values)) string {
+	forwarded := fromTrustedProxy(c)
+
+	scheme := ""
+	if forwarded {
+		scheme = c.Request.Header.Get("X-Forwarded-Proto")
+	}
+
+	if len(scheme) == 0 {
+		scheme = "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+	}
+
+	host := ""
+	if forwarded {
+		host = c.Request.Header.Get("X-Forwarded-Host")
+	}
+
+	if len(host) == 0 {
+		host = c.Request.Host
+	}
+
+	query := c.Request.URL.Query()
+	modify(query)
+
+	link := url.URL{
+		Path:     c.Request.URL.Path,
+		RawQuery: query.Encode(),
+	}
+
+	if len(host) > 0 {
+		link.Scheme = strings.TrimSpace(strings.Split(scheme, ",")[0])
+		link.Host = strings.TrimSpace(strings.Split(host, ",")[0])
+	}
+
+	return link.String()
+}
@@ -9,35 +9,7 @@ import (
 
 //TenantValidator Middleware
 func TenantValidator(excludeList map[string]interface{}) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		public := false
-
-		if _, ok := excludeList[c.Request.URL.Path]; ok {
-			public = true
-		}
-
-		if !public && strings.Contains(c.Request.URL.Path, "/swagger/") {
-			public = true
-		}
-
-		if !public && strings.Contains(c.Request.URL.Path, "/thirdpartySwagger/") {
-			public = true
-		}
-
-		if !public {
-			tenantID := c.Request.Header.Get("X-Tenant-Id")
-
-			if len(tenantID) == 0 {
-				common.AccessDenied(c, "")
-				c.Abort()
-				return
-			}
-
-			c.Set("tenantId", tenantID)
-		}
-
-		c.Next()
-	}
+	return TenantValidatorWithConfig(TenantConfig{Exclude: excludeList})
 }
 
 //VendorValidator Middleware
@@ -0,0 +1,430 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IdempotencyConfig - Store and retention of the Idempotency-Key responses
+type IdempotencyConfig struct {
+	//Store is in memory by default
+	Store IdempotencyStore
+	//TTL keeps the response for replays, 24 hours by default
+	TTL time.Duration
+	//Lease keeps the in flight record of a request, REQUEST_TIMEOUT plus 30 seconds by default,
+	//so that the key can be used again when the process stops before the response is stored
+	Lease time.Duration
+	//Methods are POST and PATCH by default
+	Methods []string
+}
+
+// IdempotencyRecord - Request hash and response of an Idempotency-Key
+type IdempotencyRecord struct {
+	RequestHash string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore - Storage of the Idempotency-Key records
+type IdempotencyStore interface {
+	// Lock creates the in flight record of the key for the lease. The existing record and
+	// false are returned when the key is already used.
+	Lock(key string, requestHash string, lease time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete stores the response of the key for the ttl
+	Complete(key string, record IdempotencyRecord, ttl time.Duration) error
+	// Release removes the in flight record so that the request can be retried
+	Release(key string) error
+}
+
+// responses are replayed without the headers of the original request
+var idempotencySkipHeaders = map[string]bool{
+	"X-Request-Id": true,
+	"Date":         true,
+	"Set-Cookie":   true,
+}
+
+// Idempotency Middleware - Store the first response of the requests with an Idempotency-Key header
+// for the tenant and replay it for the retries. Reusing the key for another request body responds
+// 422 and a retry while the first request is in flight responds 409. Server errors are not stored
+// so that the request can be retried.
+func Idempotency(config IdempotencyConfig) gin.HandlerFunc {
+	if config.Store == nil {
+		config.Store = NewMemoryIdempotencyStore()
+	}
+
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+
+	if config.Lease <= 0 {
+		timeout, _ := time.ParseDuration(common.GetEnv("REQUEST_TIMEOUT", "30s"))
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+
+		config.Lease = timeout + 30*time.Second
+	}
+
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	methods := map[string]bool{}
+	for _, method := range config.Methods {
+		methods[strings.ToUpper(method)] = true
+	}
+
+	return func(c *gin.Context) {
+		idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+
+		if !methods[c.Request.Method] || len(idempotencyKey) == 0 {
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > 255 {
+			common.BadRequest(c, &common.ErrorData{
+				Code:    common.INVALID_ARGUMENT,
+				Message: "Idempotency-Key must not be longer than 255 characters",
+			})
+			c.Abort()
+			return
+		}
+
+		var body []byte
+
+		if c.Request.Body != nil {
+			var err error
+
+			if body, err = ioutil.ReadAll(c.Request.Body); err != nil {
+				common.BadRequestWithMessage(c, "Request body could not be read")
+				c.Abort()
+				return
+			}
+
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		storeKey := idempotencyTenant(c) + ":" + idempotencyKey
+		record, created, err := config.Store.Lock(storeKey, requestHash, config.Lease)
+
+		if err != nil {
+			idempotencyWarn(c, err)
+			c.Next()
+			return
+		}
+
+		if !created {
+			replayIdempotency(c, record, requestHash)
+			c.Abort()
+			return
+		}
+
+		completed := false
+
+		// a failed or panicking request releases the key for the retry
+		defer func() {
+			if !completed {
+				if err := config.Store.Release(storeKey); err != nil {
+					idempotencyWarn(c, err)
+				}
+			}
+		}()
+
+		// the headers set by the earlier middleware, e.g. RateLimit, are not replayed
+		earlierHeader := c.Writer.Header().Clone()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		status := writer.Status()
+
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		header := http.Header{}
+		for key, values := range writer.Header() {
+			if !idempotencySkipHeaders[key] && !sameHeaderValues(earlierHeader[key], values) {
+				header[key] = values
+			}
+		}
+
+		err = config.Store.Complete(storeKey, IdempotencyRecord{
+			RequestHash: requestHash,
+			Completed:   true,
+			Status:      status,
+			Header:      header,
+			Body:        writer.body.Bytes(),
+		}, config.TTL)
+
+		completed = err == nil
+
+		if err != nil {
+			idempotencyWarn(c, err)
+		}
+	}
+}
+
+func idempotencyWarn(c *gin.Context, err error) {
+	if log, ok := c.Get("log"); ok {
+		if microLog, ok := log.(*common.MicroLog); ok {
+			microLog.Logger().Warn("Idempotency store failed: " + err.Error())
+		}
+	}
+}
+
+// replayIdempotency responds the stored response of the key or the reuse errors
+func replayIdempotency(c *gin.Context, record *IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		common.ErrorResponseWitCode(c, http.StatusUnprocessableEntity, &common.ErrorData{
+			Code:    common.INVALID_ARGUMENT,
+			Message: "Idempotency-Key was used for another request",
+		})
+		return
+	}
+
+	if !record.Completed {
+		c.Header("Retry-After", "1")
+		common.ErrorResponseWitCode(c, http.StatusConflict, &common.ErrorData{
+			Code:    common.ABORTED,
+			Message: "Request with the Idempotency-Key is in progress",
+		})
+		return
+	}
+
+	for key, values := range record.Header {
+		c.Writer.Header()[key] = values
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Status(record.Status)
+	c.Writer.Write(record.Body)
+}
+
+// idempotencyTenant returns the tenant scope of the key
+func idempotencyTenant(c *gin.Context) string {
+	if tenantID := c.GetString("tenantId"); len(tenantID) > 0 {
+		return tenantID
+	}
+
+	return c.Request.Header.Get("X-Tenant-Id")
+}
+
+// idempotencyWriter keeps a copy of the response body
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// MemoryIdempotencyStore - Idempotency store of a single service instance
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryIdempotencyRecord
+	nextSweep time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore - Create the in memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]memoryIdempotencyRecord{}}
+}
+
+// Lock - Create the in flight record of the key
+func (s *MemoryIdempotencyStore) Lock(key string, requestHash string, lease time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if now.After(s.nextSweep) {
+		for recordKey, record := range s.records {
+			if now.After(record.expires) {
+				delete(s.records, recordKey)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.expires) {
+		record := existing.record
+		return &record, false, nil
+	}
+
+	s.records[key] = memoryIdempotencyRecord{
+		record:  IdempotencyRecord{RequestHash: requestHash},
+		expires: now.Add(lease),
+	}
+
+	return nil, true, nil
+}
+
+// Complete - Store the response of the key
+func (s *MemoryIdempotencyStore) Complete(key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryIdempotencyRecord{
+		record:  record,
+		expires: time.Now().Add(ttl),
+	}
+
+	return nil
+}
+
+// Release - Remove the in flight record of the key
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; ok && !existing.record.Completed {
+		delete(s.records, key)
+	}
+
+	return nil
+}
+
+// IdempotencyEntry - Idempotency-Key record of the gorm store, migrate it with
+// db.AutoMigrate(&middleware.IdempotencyEntry{})
+type IdempotencyEntry struct {
+	Key         string `gorm:"column:idempotency_key;primaryKey;size:300"`
+	RequestHash string `gorm:"size:64"`
+	Completed   bool
+	Status      int
+	Header      string `gorm:"type:text"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index"`
+	CreatedAt   time.Time
+}
+
+// TableName - Table of the idempotency keys
+func (IdempotencyEntry) TableName() string {
+	return "idempotency_keys"
+}
+
+// GormIdempotencyStore - Idempotency store shared by the service instances through the database
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyStore - Create the idempotency store on the idempotency_keys table
+func NewGormIdempotencyStore(db *gorm.DB) *GormIdempotencyStore {
+	return &GormIdempotencyStore{db: db}
+}
+
+// Lock - Insert the in flight record of the key, the primary key rejects the concurrent requests
+func (s *GormIdempotencyStore) Lock(key string, requestHash string, lease time.Duration) (*IdempotencyRecord, bool, error) {
+	now := time.Now()
+
+	// the expired record of the key is removed so that the key can be used again
+	if err := s.db.Where("idempotency_key = ? AND expires_at < ?", key, now).Delete(&IdempotencyEntry{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	err := s.db.Create(&IdempotencyEntry{
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(lease),
+	}).Error
+
+	if err == nil {
+		return nil, true, nil
+	}
+
+	if common.CheckDbError(err) != common.ALREADY_EXISTS {
+		return nil, false, err
+	}
+
+	var entry IdempotencyEntry
+
+	if err := s.db.Where("idempotency_key = ?", key).First(&entry).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := &IdempotencyRecord{
+		RequestHash: entry.RequestHash,
+		Completed:   entry.Completed,
+		Status:      entry.Status,
+		Header:      http.Header{},
+		Body:        entry.Body,
+	}
+
+	if len(entry.Header) > 0 {
+		if err := json.Unmarshal([]byte(entry.Header), &record.Header); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return record, false, nil
+}
+
+// Complete - Store the response of the key
+func (s *GormIdempotencyStore) Complete(key string, record IdempotencyRecord, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(&IdempotencyEntry{}).Where("idempotency_key = ?", key).Updates(map[string]interface{}{
+		"request_hash": record.RequestHash,
+		"completed":    true,
+		"status":       record.Status,
+		"header":       string(header),
+		"body":         record.Body,
+		"expires_at":   time.Now().Add(ttl),
+	}).Error
+}
+
+// Release - Remove the in flight record of the key
+func (s *GormIdempotencyStore) Release(key string) error {
+	return s.db.Where("idempotency_key = ? AND completed = ?", key, false).Delete(&IdempotencyEntry{}).Error
+}
+
+func sameHeaderValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int32
+
+	store := NewMemoryIdempotencyStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(Idempotency(IdempotencyConfig{Store: store}))
+	router.POST("/orders", func(c *gin.Context) {
+		call := atomic.AddInt32(&calls, 1)
+		c.String(http.StatusCreated, "order "+strconv.Itoa(int(call)))
+	})
+	router.POST("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	post := func(path string, key string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set("Idempotency-Key", key)
+		request.Header.Set("X-Tenant-Id", "acme")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		body     string
+		status   int
+		response string
+		replayed bool
+	}{
+		{name: "first request", key: "key-1", body: `{"qty":1}`, status: http.StatusCreated, response: "order 1"},
+		{name: "replay", key: "key-1", body: `{"qty":1}`, status: http.StatusCreated, response: "order 1", replayed: true},
+		{name: "key of another request", key: "key-1", body: `{"qty":2}`, status: http.StatusUnprocessableEntity},
+		{name: "new key", key: "key-2", body: `{"qty":1}`, status: http.StatusCreated, response: "order 2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := post("/orders", test.key, test.body)
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+
+			if len(test.response) > 0 && recorder.Body.String() != test.response {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), test.response)
+			}
+
+			if replayed := recorder.Header().Get("Idempotent-Replayed") == "true"; replayed != test.replayed {
+				t.Errorf("replayed = %v, want %v", replayed, test.replayed)
+			}
+		})
+	}
+
+	t.Run("request in flight", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			done <- post("/slow", "key-3", "")
+		}()
+
+		<-started
+
+		if recorder := post("/slow", "key-3", ""); recorder.Code != http.StatusConflict {
+			t.Errorf("status = %d, want 409", recorder.Code)
+		}
+
+		close(release)
+
+		if recorder := <-done; recorder.Code != http.StatusCreated {
+			t.Errorf("status = %d, want 201", recorder.Code)
+		}
+	})
+}
+
+func TestIdempotencyLease(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+
+	// the in flight record of a request which never completed
+	if _, created, _ := store.Lock("acme:key-1", "hash", 10*time.Millisecond); !created {
+		t.Fatal("key is not locked")
+	}
+
+	if _, created, _ := store.Lock("acme:key-1", "hash", time.Minute); created {
+		t.Error("key is locked again within the lease")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, created, _ := store.Lock("acme:key-1", "hash", time.Minute); !created {
+		t.Error("key is not released after the lease")
+	}
+}
+
+func TestIdempotencyReplayHeaders(t *testing.T) {
+	var remaining int32 = 10
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Header("RateLimit-Remaining", strconv.Itoa(int(atomic.AddInt32(&remaining, -1))))
+	})
+	router.Use(Idempotency(IdempotencyConfig{Store: NewMemoryIdempotencyStore()}))
+	router.POST("/orders", func(c *gin.Context) {
+		c.Header("Location", "/orders/1")
+		c.Status(http.StatusCreated)
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"qty":1}`))
+		request.Header.Set("Idempotency-Key", "key-1")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	post()
+	replay := post()
+
+	if location := replay.Header().Get("Location"); location != "/orders/1" {
+		t.Errorf("Location = %q, want the header of the handler", location)
+	}
+
+	if limit := replay.Header().Get("RateLimit-Remaining"); limit != "8" {
+		t.Errorf("RateLimit-Remaining = %q, want the current value 8", limit)
+	}
+}
@@ -74,6 +74,7 @@ func LoggerToFile(filePath string, fileName string) gin.HandlerFunc {
 			"userAgent": userAgent,
 			"traceId":   traceID,
 			"spanId":    spanID,
+			"requestId": common.RequestID(c),
 			"ip":        clientIP,
 			"method":    reqMethod,
 			"uri":       c.Request.RequestURI,
@@ -172,6 +173,7 @@ func ConsoleLogger(level string) gin.HandlerFunc {
 			"userAgent":   userAgent,
 			"traceId":     traceID,
 			"spanId":      spanID,
+			"requestId":   common.RequestID(c),
 			"ip":          clientIP,
 			"method":      reqMethod,
 			"uri":         c.Request.RequestURI,
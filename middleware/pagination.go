@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+//Pagination Middleware - Override the service pagination config for the route
+func Pagination(config common.PaginationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("paginationConfig", config)
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+// clientIP returns the connection peer address, or the client address forwarded by the trusted
+// proxies in X-Forwarded-For or X-Real-Ip. The forwarded addresses are read from the right so
+// that a client can not choose its address by sending the headers.
+func clientIP(c *gin.Context, proxies []*net.IPNet) string {
+	ip := common.RemoteIP(c)
+
+	if !common.TrustedProxy(ip, proxies) {
+		return ipString(ip, c.Request.RemoteAddr)
+	}
+
+	forwarded := strings.Split(c.Request.Header.Get("X-Forwarded-For"), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			break
+		}
+
+		ip = forwardedIP
+
+		if !common.TrustedProxy(ip, proxies) {
+			return ip.String()
+		}
+	}
+
+	if realIP := net.ParseIP(strings.TrimSpace(c.Request.Header.Get("X-Real-Ip"))); realIP != nil {
+		return realIP.String()
+	}
+
+	return ipString(ip, c.Request.RemoteAddr)
+}
+
+func ipString(ip net.IP, fallback string) string {
+	if ip == nil {
+		return fallback
+	}
+
+	return ip.String()
+}
@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RateLimitTokenBucket   = "token_bucket"
+	RateLimitSlidingWindow = "sliding_window"
+)
+
+// RateLimitKey - Key of the rate limit bucket of the request, empty key is not limited
+type RateLimitKey func(c *gin.Context) string
+
+// RateLimitConfig - Limit of requests per window for the key
+type RateLimitConfig struct {
+	Limit  int
+	Window time.Duration
+	//Algorithm is token_bucket or sliding_window, token_bucket by default
+	Algorithm string
+	//Key is KeyByTenant by default
+	Key RateLimitKey
+	//Store is shared by the service instances, in memory by default
+	Store RateLimitStore
+}
+
+// RateLimitState - Rate limit state of a key kept in the store
+type RateLimitState struct {
+	Tokens      float64   `json:"tokens"`
+	Updated     time.Time `json:"updated"`
+	WindowStart time.Time `json:"windowStart"`
+	Count       int64     `json:"count"`
+	PrevCount   int64     `json:"prevCount"`
+}
+
+// RateLimitStore - Storage of the rate limit state. Update must apply the update to the
+// state of the key atomically, state is nil for a new key, and keep it for the ttl.
+type RateLimitStore interface {
+	Update(key string, ttl time.Duration, update func(state *RateLimitState) *RateLimitState) error
+}
+
+// rateLimitResult is the outcome of a request against the limit
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// RateLimit Middleware - Limit the requests of the key and respond 429 RESOURCE_EXHAUSTED with
+// Retry-After when exceeded. RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset are set on
+// every response. The request is allowed when the store fails.
+func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+
+	if config.Algorithm != RateLimitSlidingWindow {
+		config.Algorithm = RateLimitTokenBucket
+	}
+
+	if config.Key == nil {
+		config.Key = KeyByTenant
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(c *gin.Context) {
+		key := config.Key(c)
+
+		if config.Limit <= 0 || len(key) == 0 {
+			c.Next()
+			return
+		}
+
+		var result rateLimitResult
+		now := time.Now()
+
+		err := config.Store.Update(config.Algorithm+":"+key, 2*config.Window, func(state *RateLimitState) *RateLimitState {
+			if config.Algorithm == RateLimitSlidingWindow {
+				state, result = slidingWindow(state, config, now)
+			} else {
+				state, result = tokenBucket(state, config, now)
+			}
+
+			return state
+		})
+
+		if err != nil {
+			if log, ok := c.Get("log"); ok {
+				if microLog, ok := log.(*common.MicroLog); ok {
+					microLog.Logger().Warn("Rate limit store failed: " + err.Error())
+				}
+			}
+
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(config.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(seconds(result.reset)))
+
+		if !result.allowed {
+			c.Header("Retry-After", strconv.Itoa(seconds(result.retryAfter)))
+			common.TooManyRequests(c, "")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tokenBucket refills Limit tokens per window and takes a token for the request
+func tokenBucket(state *RateLimitState, config RateLimitConfig, now time.Time) (*RateLimitState, rateLimitResult) {
+	limit := float64(config.Limit)
+	rate := limit / config.Window.Seconds()
+
+	if state == nil {
+		state = &RateLimitState{Tokens: limit, Updated: now}
+	}
+
+	elapsed := now.Sub(state.Updated).Seconds()
+	if elapsed > 0 {
+		state.Tokens = math.Min(limit, state.Tokens+elapsed*rate)
+		state.Updated = now
+	}
+
+	result := rateLimitResult{}
+
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = secondsDuration((1 - state.Tokens) / rate)
+	}
+
+	result.remaining = int(math.Floor(state.Tokens))
+	result.reset = secondsDuration((limit - state.Tokens) / rate)
+
+	return state, result
+}
+
+// slidingWindow counts the requests of the current window plus the weighted count of the
+// previous window
+func slidingWindow(state *RateLimitState, config RateLimitConfig, now time.Time) (*RateLimitState, rateLimitResult) {
+	window := config.Window
+	windowStart := now.Truncate(window)
+
+	if state == nil {
+		state = &RateLimitState{WindowStart: windowStart}
+	}
+
+	if !state.WindowStart.Equal(windowStart) {
+		if state.WindowStart.Equal(windowStart.Add(-window)) {
+			state.PrevCount = state.Count
+		} else {
+			state.PrevCount = 0
+		}
+
+		state.Count = 0
+		state.WindowStart = windowStart
+	}
+
+	limit := float64(config.Limit)
+	elapsed := now.Sub(windowStart)
+	weight := 1 - elapsed.Seconds()/window.Seconds()
+	estimated := float64(state.PrevCount)*weight + float64(state.Count)
+
+	result := rateLimitResult{reset: windowStart.Add(window).Sub(now)}
+
+	if estimated+1 <= limit {
+		state.Count++
+		estimated++
+		result.allowed = true
+	} else {
+		result.retryAfter = result.reset
+
+		// the weighted previous window count decreases until the request fits
+		if free := limit - 1 - float64(state.Count); free >= 0 && state.PrevCount > 0 {
+			wait := window.Seconds()*(1-free/float64(state.PrevCount)) - elapsed.Seconds()
+			result.retryAfter = secondsDuration(wait)
+		}
+	}
+
+	result.remaining = int(math.Max(0, math.Floor(limit-estimated)))
+
+	return state, result
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// seconds rounds the duration up to whole seconds for the headers
+func seconds(duration time.Duration) int {
+	return int(math.Ceil(math.Max(0, duration.Seconds())))
+}
+
+// KeyByTenant - Rate limit key of the tenant set by TenantValidator. The requests are limited
+// by the peer address when the tenant is not validated yet, the X-Tenant-Id header is not used
+// so that a client can not get a new limit by changing it.
+func KeyByTenant(c *gin.Context) string {
+	if tenantID := c.GetString("tenantId"); len(tenantID) > 0 {
+		return "tenant:" + tenantID
+	}
+
+	return KeyByIP(c)
+}
+
+// KeyByUser - Rate limit key of the user
+func KeyByUser(c *gin.Context) string {
+	if userID := c.Request.Header.Get("X-User-Id"); len(userID) > 0 {
+		return "user:" + userID
+	}
+
+	return ""
+}
+
+// KeyByIP - Rate limit key of the connection peer address. Behind a proxy or load balancer
+// every client shares the address of the proxy, use KeyByClientIP with the proxy addresses.
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + clientIP(c, nil)
+}
+
+// KeyByClientIP - Rate limit key of the client IP forwarded by the trusted proxies (addresses
+// or CIDR ranges) in X-Forwarded-For or X-Real-Ip. The headers of the other peers are ignored
+// so that a client can not get around the limit by rotating them.
+func KeyByClientIP(trustedProxies ...string) RateLimitKey {
+	proxies := common.ParseTrustedProxies(trustedProxies)
+
+	return func(c *gin.Context) string {
+		return "ip:" + clientIP(c, proxies)
+	}
+}
+
+// KeyByRoute - Rate limit key of the route
+func KeyByRoute(c *gin.Context) string {
+	route := c.FullPath()
+
+	if len(route) == 0 {
+		route = c.Request.URL.Path
+	}
+
+	return "route:" + c.Request.Method + " " + route
+}
+
+// KeyBy - Combine the keys, e.g. KeyBy(KeyByTenant, KeyByRoute) limits each tenant per route.
+// The request is not limited when one of the keys is empty.
+func KeyBy(keys ...RateLimitKey) RateLimitKey {
+	return func(c *gin.Context) string {
+		parts := make([]string, 0, len(keys))
+
+		for _, key := range keys {
+			part := key(c)
+			if len(part) == 0 {
+				return ""
+			}
+			parts = append(parts, part)
+		}
+
+		return strings.Join(parts, "|")
+	}
+}
+
+// MemoryRateLimitStore - Rate limit store of a single service instance
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRateLimitEntry
+	nextSweep time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state   *RateLimitState
+	expires time.Time
+}
+
+// NewMemoryRateLimitStore - Create the in memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: map[string]memoryRateLimitEntry{}}
+}
+
+// Update - Update the state of the key under the store lock
+func (s *MemoryRateLimitStore) Update(key string, ttl time.Duration, update func(state *RateLimitState) *RateLimitState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if now.After(s.nextSweep) {
+		for entryKey, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, entryKey)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	var state *RateLimitState
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expires) {
+		state = entry.state
+	}
+
+	s.entries[key] = memoryRateLimitEntry{
+		state:   update(state),
+		expires: now.Add(ttl),
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKeyByClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		proxies    []string
+		key        string
+	}{
+		{name: "peer address", remoteAddr: "203.0.113.7:1234", key: "ip:203.0.113.7"},
+		{name: "forwarded by an untrusted peer", remoteAddr: "203.0.113.7:1234", forwarded: "198.51.100.1", key: "ip:203.0.113.7"},
+		{
+			name:       "forwarded by a trusted proxy",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  "198.51.100.1",
+			proxies:    []string{"10.0.0.0/8"},
+			key:        "ip:198.51.100.1",
+		},
+		{
+			name:       "address spoofed by the client before the proxy",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  "192.0.2.99, 198.51.100.1",
+			proxies:    []string{"10.0.0.0/8"},
+			key:        "ip:198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.2:1234",
+			forwarded:  "198.51.100.1, 10.0.0.3",
+			proxies:    []string{"10.0.0.0/8"},
+			key:        "ip:198.51.100.1",
+		},
+		{
+			name:       "real ip of a trusted proxy",
+			remoteAddr: "10.0.0.2:1234",
+			realIP:     "198.51.100.1",
+			proxies:    []string{"10.0.0.2"},
+			key:        "ip:198.51.100.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = test.remoteAddr
+
+			if len(test.forwarded) > 0 {
+				c.Request.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+
+			if len(test.realIP) > 0 {
+				c.Request.Header.Set("X-Real-Ip", test.realIP)
+			}
+
+			if key := KeyByClientIP(test.proxies...)(c); key != test.key {
+				t.Errorf("key = %q, want %q", key, test.key)
+			}
+		})
+	}
+}
+
+func TestKeyByTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID string
+		header   string
+		key      string
+	}{
+		{name: "validated tenant", tenantID: "acme", header: "other", key: "tenant:acme"},
+		{name: "tenant header only", header: "other", key: "ip:192.0.2.1"},
+		{name: "no tenant", key: "ip:192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header.Set("X-Tenant-Id", test.header)
+
+			if len(test.tenantID) > 0 {
+				c.Set("tenantId", test.tenantID)
+			}
+
+			if key := KeyByTenant(c); key != test.key {
+				t.Errorf("key = %q, want %q", key, test.key)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	for _, algorithm := range []string{RateLimitTokenBucket, RateLimitSlidingWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RateLimit(RateLimitConfig{Limit: 2, Window: time.Minute, Algorithm: algorithm, Key: KeyByIP}))
+			router.GET("/", func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			statuses := make([]int, 0)
+
+			for i := 0; i < 3; i++ {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.RemoteAddr = "203.0.113.7:1234"
+				// rotating the forwarded address does not reset the limit
+				request.Header.Set("X-Forwarded-For", "198.51.100."+string(rune('1'+i)))
+
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+				statuses = append(statuses, recorder.Code)
+
+				if i == 2 && len(recorder.Header().Get("Retry-After")) == 0 {
+					t.Error("Retry-After is not set")
+				}
+			}
+
+			if statuses[0] != http.StatusNoContent || statuses[1] != http.StatusNoContent || statuses[2] != http.StatusTooManyRequests {
+				t.Errorf("statuses = %v, want 204 204 429", statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+//Recovery Middleware - Recover the handler panics, log the panic with the stack trace and respond
+//with the standard error. The panic is shown in the error message when GIN_ENV is development or test.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+
+			if recovered == nil {
+				return
+			}
+
+			stackTrace := string(debug.Stack())
+
+			// the panic of a handler run by the timeout middleware is raised again from its goroutine
+			if panicStack := c.GetString("panicStack"); len(panicStack) > 0 {
+				stackTrace = panicStack
+			}
+			message := fmt.Sprint("panic: ", recovered)
+
+			recoveryLogger(c).WithField("stack_trace", stackTrace).Error(message)
+
+			// the client is gone, nothing can be written to the connection
+			if brokenPipe(recovered) {
+				c.Abort()
+				return
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			// the panic details are shown to the developers only
+			env := common.GetEnv("GIN_ENV", "")
+
+			if env != "development" && env != "test" {
+				message = ""
+			}
+
+			common.InternalServerError(c, message)
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// recoveryLogger returns the request log or a JSON logger when the log middleware did not run
+func recoveryLogger(c *gin.Context) *logrus.Entry {
+	if log, ok := c.Get("log"); ok {
+		if microLog, ok := log.(*common.MicroLog); ok && microLog.Log != nil {
+			return microLog.Logger()
+		}
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return logger.WithFields(logrus.Fields{
+		"requestId": common.RequestID(c),
+		"method":    c.Request.Method,
+		"uri":       c.Request.RequestURI,
+	})
+}
+
+func brokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	message := strings.ToLower(opErr.Error())
+
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
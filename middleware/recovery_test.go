@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecoveryPanicMessage(t *testing.T) {
+	tests := []struct {
+		env   string
+		shown bool
+	}{
+		{env: "development", shown: true},
+		{env: "test", shown: true},
+		{env: "", shown: false},
+		{env: "prod", shown: false},
+		{env: "production", shown: false},
+	}
+
+	defer os.Unsetenv("GIN_ENV")
+
+	for _, test := range tests {
+		t.Run("GIN_ENV="+test.env, func(t *testing.T) {
+			os.Setenv("GIN_ENV", test.env)
+
+			logger := logrus.New()
+			logger.SetOutput(ioutil.Discard)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("log", &common.MicroLog{C: c, Fields: map[string]interface{}{}, Log: logger})
+				c.Next()
+			})
+			router.Use(Recovery())
+			router.GET("/", panickingHandler)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if recorder.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", recorder.Code)
+			}
+
+			if shown := strings.Contains(recorder.Body.String(), "handler failed"); shown != test.shown {
+				t.Errorf("panic shown = %v, want %v: %s", shown, test.shown, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+//RequestID Middleware - Accept the request id from X-Request-Id, traceparent or B3 headers or
+//generate a new one, set it in the context and the log fields and echo it as X-Request-Id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := common.RequestIDFromHeader(c.Request.Header)
+
+		if len(requestID) == 0 {
+			requestID = common.NewRequestID()
+		}
+
+		c.Set("requestId", requestID)
+		c.Request.Header.Set("X-Request-Id", requestID)
+		c.Header("X-Request-Id", requestID)
+
+		if log, ok := c.Get("log"); ok {
+			if microLog, ok := log.(*common.MicroLog); ok && microLog.Fields != nil {
+				microLog.Fields["requestId"] = requestID
+
+				if microLog.Log != nil {
+					microLog.ContextLog = microLog.Log.WithFields(microLog.Fields)
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
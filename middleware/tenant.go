@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantResolver - Resolve the tenant id of the request, empty id when the request has none
+type TenantResolver func(c *gin.Context) (string, error)
+
+// TenantConfig - Resolvers tried in order and the optional store validating the tenant
+type TenantConfig struct {
+	//Resolvers are TenantFromHeader("X-Tenant-Id") by default
+	Resolvers []TenantResolver
+	//Store rejects unknown and suspended tenants and sets the tenant in the context
+	Store common.TenantStore
+	//Exclude lists the public paths
+	Exclude map[string]interface{}
+}
+
+// errInvalidTenantToken is returned by the claim resolver for tokens failing the validation
+var errInvalidTenantToken = errors.New("invalid token")
+
+// TenantValidatorWithConfig Middleware - Resolve the tenant of the request with the resolvers,
+// validate it with the store and set tenantId and tenant in the context
+func TenantValidatorWithConfig(config TenantConfig) gin.HandlerFunc {
+	if len(config.Resolvers) == 0 {
+		config.Resolvers = []TenantResolver{TenantFromHeader("X-Tenant-Id")}
+	}
+
+	return func(c *gin.Context) {
+		if publicPath(c, config.Exclude) {
+			c.Next()
+			return
+		}
+
+		tenantID := ""
+
+		for _, resolver := range config.Resolvers {
+			id, err := resolver(c)
+
+			if err != nil {
+				common.ErrorResponseWitCode(c, http.StatusUnauthorized, &common.ErrorData{
+					Code:    common.UNAUTHENTICATED,
+					Message: "Tenant could not be resolved: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+
+			if tenantID = strings.TrimSpace(id); len(tenantID) > 0 {
+				break
+			}
+		}
+
+		if len(tenantID) == 0 {
+			common.AccessDenied(c, "")
+			c.Abort()
+			return
+		}
+
+		if config.Store != nil {
+			tenant, err := config.Store.GetTenant(c.Request.Context(), tenantID)
+
+			switch {
+			case err != nil:
+				common.RespondError(c, err)
+			case tenant == nil:
+				common.RespondError(c, common.ErrUnknownTenant)
+			case len(tenant.Status) > 0 && tenant.Status != common.TenantActive:
+				common.RespondError(c, common.ErrTenantSuspended)
+			default:
+				c.Set("tenant", tenant)
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("tenantId", tenantID)
+
+		// the resolved tenant is propagated to the logs and the outbound calls
+		c.Request.Header.Set("X-Tenant-Id", tenantID)
+
+		c.Next()
+	}
+}
+
+// publicPath tells whether the path is excluded from the tenant validation
+func publicPath(c *gin.Context, excludeList map[string]interface{}) bool {
+	if _, ok := excludeList[c.Request.URL.Path]; ok {
+		return true
+	}
+
+	return strings.Contains(c.Request.URL.Path, "/swagger/") ||
+		strings.Contains(c.Request.URL.Path, "/thirdpartySwagger/")
+}
+
+// TenantFromHeader - Resolve the tenant from the request header
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *gin.Context) (string, error) {
+		return c.Request.Header.Get(name), nil
+	}
+}
+
+// TenantFromQuery - Resolve the tenant from the query param
+func TenantFromQuery(name string) TenantResolver {
+	return func(c *gin.Context) (string, error) {
+		return c.Query(name), nil
+	}
+}
+
+// TenantFromSubdomain - Resolve the tenant from the subdomain of the base domain,
+// e.g. acme of acme.example.com for the example.com base domain. X-Forwarded-Host is
+// used only for the requests of the trusted proxies (addresses or CIDR ranges).
+func TenantFromSubdomain(baseDomain string, trustedProxies ...string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	proxies := common.ParseTrustedProxies(trustedProxies)
+
+	return func(c *gin.Context) (string, error) {
+		host := c.Request.Host
+
+		if forwardedHost := c.Request.Header.Get("X-Forwarded-Host"); len(forwardedHost) > 0 && common.TrustedProxy(common.RemoteIP(c), proxies) {
+			host = forwardedHost
+		}
+
+		host = strings.ToLower(strings.TrimSpace(strings.Split(host, ",")[0]))
+
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+
+		if !strings.HasSuffix(host, suffix) {
+			return "", nil
+		}
+
+		labels := strings.Split(strings.TrimSuffix(host, suffix), ".")
+
+		return labels[len(labels)-1], nil
+	}
+}
+
+// TenantFromPathPrefix - Resolve the tenant from the path segment after the prefix,
+// e.g. acme of /tenants/acme/products for the /tenants prefix
+func TenantFromPathPrefix(prefix string) TenantResolver {
+	prefix = "/" + strings.Trim(prefix, "/") + "/"
+
+	return func(c *gin.Context) (string, error) {
+		path := c.Request.URL.Path
+
+		if !strings.HasPrefix(path, prefix) {
+			return "", nil
+		}
+
+		return strings.Split(strings.TrimPrefix(path, prefix), "/")[0], nil
+	}
+}
+
+// TenantFromJWTClaim - Resolve the tenant from the claim of the bearer token validated with
+// the key function, e.g. common.SSOKeyFunc(jwksUri). A request without token has no tenant.
+func TenantFromJWTClaim(claim string, keyFunc jwt.Keyfunc) TenantResolver {
+	return func(c *gin.Context) (string, error) {
+		authorization := c.Request.Header.Get("Authorization")
+
+		if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+			return "", nil
+		}
+
+		claims := jwt.MapClaims{}
+		token, err := jwt.ParseWithClaims(strings.TrimSpace(authorization[7:]), claims, keyFunc)
+
+		if err != nil || !token.Valid {
+			return "", errInvalidTenantToken
+		}
+
+		switch value := claims[claim].(type) {
+		case string:
+			return value, nil
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64), nil
+		}
+
+		return "", nil
+	}
+}
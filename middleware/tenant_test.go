@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenantFromSubdomain(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		host          string
+		forwardedHost string
+		proxies       []string
+		tenant        string
+	}{
+		{name: "host", remoteAddr: "203.0.113.7:1234", host: "acme.example.com", tenant: "acme"},
+		{name: "host with port", remoteAddr: "203.0.113.7:1234", host: "acme.example.com:8080", tenant: "acme"},
+		{name: "other domain", remoteAddr: "203.0.113.7:1234", host: "acme.other.com", tenant: ""},
+		{
+			name:          "forwarded host without trusted proxies",
+			remoteAddr:    "203.0.113.7:1234",
+			host:          "acme.example.com",
+			forwardedHost: "evil.example.com",
+			tenant:        "acme",
+		},
+		{
+			name:          "forwarded host of an untrusted client",
+			remoteAddr:    "203.0.113.7:1234",
+			host:          "acme.example.com",
+			forwardedHost: "evil.example.com",
+			proxies:       []string{"10.0.0.0/8"},
+			tenant:        "acme",
+		},
+		{
+			name:          "forwarded host of a trusted proxy",
+			remoteAddr:    "10.1.2.3:1234",
+			host:          "internal",
+			forwardedHost: "acme.example.com",
+			proxies:       []string{"10.0.0.0/8"},
+			tenant:        "acme",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = test.remoteAddr
+			c.Request.Host = test.host
+
+			if len(test.forwardedHost) > 0 {
+				c.Request.Header.Set("X-Forwarded-Host", test.forwardedHost)
+			}
+
+			tenant, err := TenantFromSubdomain("example.com", test.proxies...)(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tenant != test.tenant {
+				t.Errorf("tenant = %q, want %q", tenant, test.tenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout Middleware - Attach a deadline to the request context and respond 504 DEADLINE_EXCEEDED
+// when the handler has not responded in time. Zero timeout uses REQUEST_TIMEOUT (30s by default),
+// negative timeout or REQUEST_TIMEOUT=0 disables it. Use it again on a route group to override
+// the deadline of the group, Timeout(-1) removes the deadline of long running routes like exports.
+// A response streamed with Flush before the deadline is not cancelled at the deadline.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		duration := timeout
+
+		if duration == 0 {
+			duration, _ = time.ParseDuration(common.GetEnv("REQUEST_TIMEOUT", "30s"))
+		}
+
+		// a route group override moves the deadline of the outer timeout
+		if value, ok := c.Get("timeoutWriter"); ok {
+			if writer, ok := value.(*timeoutWriter); ok {
+				var deadline time.Time
+
+				if duration > 0 {
+					deadline = time.Now().Add(duration)
+				}
+
+				ctx, cancel := writer.context(deadline)
+				defer cancel()
+
+				c.Request = c.Request.WithContext(ctx)
+				c.Next()
+				return
+			}
+		}
+
+		if duration <= 0 {
+			c.Next()
+			return
+		}
+
+		deadline := time.Now().Add(duration)
+
+		writer := newTimeoutWriter(c.Writer, c.Request.Context())
+		timeoutContext := &gin.Context{
+			Request: c.Request,
+			Writer:  newTimeoutWriter(c.Writer, c.Request.Context()),
+			Keys:    map[string]interface{}{"requestId": common.RequestID(c)},
+		}
+
+		ctx, cancel := writer.context(deadline)
+		defer cancel()
+
+		c.Set("timeoutWriter", writer)
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = writer
+
+		done := make(chan interface{}, 1)
+
+		go func() {
+			var recovered interface{}
+
+			defer func() {
+				if value := recover(); value != nil {
+					recovered = value
+					// the stack of the handler goroutine is lost when the panic is raised again
+					c.Set("panicStack", string(debug.Stack()))
+				}
+				done <- recovered
+			}()
+
+			c.Next()
+		}()
+
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+
+		var recovered interface{}
+
+	wait:
+		for {
+			select {
+			case recovered = <-done:
+				break wait
+			case <-writer.reset:
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+
+				if deadline := writer.getDeadline(); !deadline.IsZero() {
+					timer.Reset(time.Until(deadline))
+				}
+			case <-timer.C:
+				timedOut := writer.timeout(func() {
+					common.ErrorResponseWitCode(timeoutContext, http.StatusGatewayTimeout, &common.ErrorData{
+						Code:    common.DEADLINE_EXCEEDED,
+						Message: "Request timed out",
+					})
+
+					response := timeoutContext.Writer.(*timeoutWriter)
+					response.header.Set("Content-Length", strconv.Itoa(response.body.Len()))
+					response.commit()
+					response.ResponseWriter.Flush()
+				})
+
+				// the streamed response keeps running after the deadline
+				if !timedOut {
+					continue
+				}
+
+				writer.cancelRequest()
+
+				// the context is reused by gin once the handler chain returns
+				recovered = <-done
+				break wait
+			}
+		}
+
+		c.Writer = writer.ResponseWriter
+
+		// the buffered response is dropped so that the recovery can respond
+		if recovered != nil {
+			panic(recovered)
+		}
+
+		writer.finish()
+
+		if writer.timedOut {
+			c.Abort()
+		}
+	}
+}
+
+// deadlineContext reports the deadline to the outbound calls but is cancelled by the middleware
+// only, so that a streamed response is not cut off at the deadline
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+	writer   *timeoutWriter
+}
+
+func (ctx *deadlineContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+func (ctx *deadlineContext) Err() error {
+	err := ctx.Context.Err()
+
+	if err == context.Canceled && ctx.writer.isTimedOut() {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+// timeoutWriter buffers the response until the handler returns, flushes or times out
+type timeoutWriter struct {
+	gin.ResponseWriter
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	written   bool
+	committed bool
+	timedOut  bool
+	mu        sync.Mutex
+	base      context.Context
+	deadline  time.Time
+	cancel    context.CancelFunc
+	reset     chan struct{}
+}
+
+func newTimeoutWriter(writer gin.ResponseWriter, base context.Context) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: writer,
+		header:         http.Header{},
+		status:         http.StatusOK,
+		base:           base,
+		reset:          make(chan struct{}, 1),
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.written || code <= 0 {
+		return
+	}
+
+	w.status = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// the late response of the handler is discarded, gin panics on write errors
+	if w.timedOut {
+		return len(data), nil
+	}
+
+	w.written = true
+
+	if w.committed {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.committed {
+		return w.ResponseWriter.Size()
+	}
+
+	if !w.written {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.written
+}
+
+// Flush sends the buffered response, the response is streamed from then on
+// and can no longer be replaced by the timeout response
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+
+	w.written = true
+	w.commit()
+	w.ResponseWriter.Flush()
+}
+
+// commit writes the status, headers and buffered body to the response, called with the lock held
+func (w *timeoutWriter) commit() {
+	if !w.committed {
+		for key, values := range w.header {
+			w.ResponseWriter.Header()[key] = values
+		}
+
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.WriteHeaderNow()
+		w.committed = true
+	}
+
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// timeout writes the timeout response unless the handler response was sent already
+func (w *timeoutWriter) timeout(respond func()) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.committed {
+		return false
+	}
+
+	w.timedOut = true
+	respond()
+
+	return true
+}
+
+func (w *timeoutWriter) isTimedOut() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.timedOut
+}
+
+// finish sends the handler response after the handler returned
+func (w *timeoutWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+
+	w.commit()
+}
+
+// context creates the request context of the deadline and moves the timer to the deadline,
+// zero deadline removes the deadline
+func (w *timeoutWriter) context(deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(w.base)
+
+	w.mu.Lock()
+	w.deadline = deadline
+	w.cancel = cancel
+	w.mu.Unlock()
+
+	select {
+	case w.reset <- struct{}{}:
+	default:
+	}
+
+	if deadline.IsZero() {
+		return ctx, cancel
+	}
+
+	return &deadlineContext{Context: ctx, deadline: deadline, writer: w}, cancel
+}
+
+// cancelRequest cancels the request context of the current deadline
+func (w *timeoutWriter) cancelRequest() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (w *timeoutWriter) getDeadline() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.deadline
+}
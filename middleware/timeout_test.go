@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	common "bitbucket.org/MarkEdwardTresidder/micro-common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func panickingHandler(c *gin.Context) {
+	panic("handler failed")
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+		body    string
+	}{
+		{
+			name: "responds before the deadline",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusCreated, "created")
+			},
+			status: http.StatusCreated,
+			body:   "created",
+		},
+		{
+			name: "responds 504 after the deadline",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.String(http.StatusOK, "late")
+			},
+			status: http.StatusGatewayTimeout,
+			body:   common.DEADLINE_EXCEEDED,
+		},
+		{
+			name: "keeps streaming the flushed response after the deadline",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "first,")
+				c.Writer.Flush()
+				time.Sleep(100 * time.Millisecond)
+
+				if err := c.Request.Context().Err(); err != nil {
+					c.String(http.StatusOK, err.Error())
+					return
+				}
+				c.String(http.StatusOK, "second")
+			},
+			status: http.StatusOK,
+			body:   "first,second",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", Timeout(30*time.Millisecond), test.handler)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+
+			if !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), test.body)
+			}
+		})
+	}
+}
+
+func TestTimeoutRouteGroupOptOut(t *testing.T) {
+	router := gin.New()
+	router.Use(Timeout(30 * time.Millisecond))
+
+	exports := router.Group("/exports", Timeout(-1))
+	exports.GET("", func(c *gin.Context) {
+		time.Sleep(60 * time.Millisecond)
+
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("deadline is set on the opted out route")
+		}
+		c.String(http.StatusOK, "exported")
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/exports", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "exported" {
+		t.Errorf("response = %d %q, want 200 exported", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestTimeoutRecoveryStackTrace(t *testing.T) {
+	var output bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("log", &common.MicroLog{C: c, Fields: map[string]interface{}{}, Log: logger})
+		c.Next()
+	})
+	router.Use(Recovery(), Timeout(time.Second))
+	router.GET("/", panickingHandler)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", recorder.Code)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("log entry = %q: %v", output.String(), err)
+	}
+
+	stackTrace, _ := entry["stack_trace"].(string)
+	if !strings.Contains(stackTrace, "panickingHandler") {
+		t.Errorf("stack_trace does not contain the handler frames:\n%s", stackTrace)
+	}
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/iancoleman/strcase"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VersionColumn - Column of the entity version incremented on every update, mapped by a
+// Version int64 `json:"version" gorm:"not null;default:1"` field of the model
+var VersionColumn = "version"
+
+// ErrVersionConflict - Entity was modified by another request since the version was read
+var ErrVersionConflict = &AppError{
+	Code:    ABORTED,
+	Message: "Resource has been modified by another request",
+	Status:  http.StatusConflict,
+	Details: []ErrorDetail{{Code: "Conflict", Target: "version", Message: "Version is not current"}},
+}
+
+// VersionScope - Restrict the update or delete to the rows still having the version
+func VersionScope(version int64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: VersionColumn},
+			Value:  version,
+		})
+	}
+}
+
+// UpdateWithVersion - Update the entity only when it still has the version and increment the
+// version. values is a map of columns or a pointer to the model, db selects the entity e.g.
+// db.Model(&product). ErrVersionConflict is returned when no row has the version.
+func UpdateWithVersion(db *gorm.DB, version int64, values interface{}) error {
+	switch v := values.(type) {
+	case map[string]interface{}:
+		updates := make(map[string]interface{}, len(v)+1)
+		for column, value := range v {
+			updates[column] = value
+		}
+		updates[VersionColumn] = version + 1
+		values = updates
+	default:
+		if err := setVersionField(values, version+1); err != nil {
+			return err
+		}
+	}
+
+	result := db.Scopes(VersionScope(version)).Updates(values)
+
+	if result.Error == nil && result.RowsAffected > 0 {
+		return nil
+	}
+
+	// the model keeps the version it was read with when the update fails
+	if _, ok := values.(map[string]interface{}); !ok {
+		setVersionField(values, version)
+	}
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return ErrVersionConflict
+}
+
+// DeleteWithVersion - Delete the entity only when it still has the version
+func DeleteWithVersion(db *gorm.DB, version int64, value interface{}) error {
+	result := db.Scopes(VersionScope(version)).Delete(value)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// IsVersionConflict - Check whether the error is a version conflict of the optimistic lock
+func IsVersionConflict(err error) bool {
+	return errors.Is(err, ErrVersionConflict)
+}
+
+// setVersionField sets the version field of the model pointer
+func setVersionField(model interface{}, version int64) error {
+	value := reflect.ValueOf(model)
+
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("optimistic lock needs a map or a model pointer")
+	}
+
+	field := value.Elem().FieldByName(strcase.ToCamel(VersionColumn))
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(version)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(version))
+	default:
+		return errors.New("model has no integer " + VersionColumn + " field")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type versionedProduct struct {
+	ID      uint
+	Name    string
+	Version int64
+}
+
+func (versionedProduct) TableName() string {
+	return "products"
+}
+
+// versionDB captures the SQL of the update and delete statements and reports the affected rows
+func versionDB(t *testing.T, rowsAffected int64, sql *string, vars *[]interface{}) *gorm.DB {
+	db := dryRunDB(t)
+
+	capture := func(db *gorm.DB) {
+		*sql = db.Statement.SQL.String()
+		*vars = db.Statement.Vars
+		db.RowsAffected = rowsAffected
+	}
+
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Callback().Delete().After("gorm:delete").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestUpdateWithVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		values       func(product *versionedProduct) interface{}
+		rowsAffected int64
+		vars         []interface{}
+		err          error
+		version      int64
+	}{
+		{
+			name:         "map updated",
+			values:       func(product *versionedProduct) interface{} { return map[string]interface{}{"name": "pear"} },
+			rowsAffected: 1,
+			vars:         []interface{}{"pear", int64(4), int64(3), uint(1)},
+			version:      4,
+		},
+		{
+			name: "model updated",
+			values: func(product *versionedProduct) interface{} {
+				product.Name = "pear"
+				return product
+			},
+			rowsAffected: 1,
+			vars:         []interface{}{"pear", int64(4), int64(3), uint(1)},
+			version:      4,
+		},
+		{
+			name: "model of another version",
+			values: func(product *versionedProduct) interface{} {
+				product.Name = "pear"
+				return product
+			},
+			rowsAffected: 0,
+			vars:         []interface{}{"pear", int64(4), int64(3), uint(1)},
+			err:          ErrVersionConflict,
+			version:      3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sql string
+			var vars []interface{}
+
+			product := versionedProduct{ID: 1, Name: "apple", Version: 3}
+			db := versionDB(t, test.rowsAffected, &sql, &vars)
+
+			err := UpdateWithVersion(db.Model(&product), 3, test.values(&product))
+
+			if err != test.err {
+				t.Errorf("err = %v, want %v", err, test.err)
+			}
+
+			if want := "UPDATE `products` SET `name`=?,`version`=? WHERE `products`.`version` = ? AND `id` = ?"; sql != want {
+				t.Errorf("sql = %s, want %s", sql, want)
+			}
+
+			if !reflect.DeepEqual(vars, test.vars) {
+				t.Errorf("vars = %#v, want %#v", vars, test.vars)
+			}
+
+			if product.Version != test.version {
+				t.Errorf("version = %d, want %d", product.Version, test.version)
+			}
+		})
+	}
+}
+
+func TestDeleteWithVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		err          error
+	}{
+		{name: "deleted", rowsAffected: 1},
+		{name: "another version", rowsAffected: 0, err: ErrVersionConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sql string
+			var vars []interface{}
+
+			db := versionDB(t, test.rowsAffected, &sql, &vars)
+
+			err := DeleteWithVersion(db, 3, &versionedProduct{ID: 1})
+
+			if err != test.err || IsVersionConflict(err) != (test.err != nil) {
+				t.Errorf("err = %v, want %v", err, test.err)
+			}
+
+			if want := "DELETE FROM `products` WHERE `products`.`version` = ? AND `products`.`id` = ?"; sql != want {
+				t.Errorf("sql = %s, want %s", sql, want)
+			}
+
+			if want := []interface{}{int64(3), uint(1)}; !reflect.DeepEqual(vars, want) {
+				t.Errorf("vars = %#v, want %#v", vars, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SortField - Allowed order field mapped to a joined column or a JSON path.
+// Use it as the value of the allowed fields in place of "true", e.g.
+//	"category.name":    SortField{Column: "categories.name", Join: "LEFT JOIN categories ON categories.id = products.category_id"}
+//	"attributes.color": SortField{Column: "attributes", JSONPath: "color"}
+type SortField struct {
+	//Column of the root table, the joined table or holding the JSON document
+	Column string
+	//Join is a join clause or association name added to the query when sorting on the field
+	Join string
+	//JSONPath is the dot separated key path inside the JSON column
+	JSONPath string
+	//Nulls is the default NULLS FIRST or LAST placement
+	Nulls string
+}
+
+// sortField - Allowed order by column with the sort direction
+type sortField struct {
+	Column    string
+	Direction string
+	Nulls     string
+	Join      string
+	JSONPath  string
+}
+
+// expression returns the order expression of the field for the dialect
+func (field sortField) expression(dialect string, table string) string {
+	column := field.Column
+
+	if len(table) > 0 && !strings.Contains(column, ".") {
+		column = table + "." + column
+	}
+
+	if len(field.JSONPath) == 0 {
+		return column
+	}
+
+	keys := strings.Split(field.JSONPath, ".")
+
+	switch dialect {
+	case "postgres":
+		return column + " #>> '{" + strings.Join(keys, ",") + "}'"
+	case "mysql":
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", '$." + field.JSONPath + "'))"
+	}
+
+	return "json_extract(" + column + ", '$." + field.JSONPath + "')"
+}
+
+// orders returns the ORDER BY items of the field, MySQL has no NULLS FIRST/LAST
+// so the placement is emulated with an IS NULL sort
+func (field sortField) orders(dialect string, table string) []string {
+	expression := field.expression(dialect, table)
+	orders := make([]string, 0, 2)
+
+	if len(field.Nulls) > 0 && dialect == "mysql" {
+		nullsOrder := "ASC"
+		if field.Nulls == "FIRST" {
+			nullsOrder = "DESC"
+		}
+		orders = append(orders, expression+" IS NULL "+nullsOrder)
+	}
+
+	order := expression + " " + field.Direction
+
+	if len(field.Nulls) > 0 && dialect != "mysql" {
+		order = order + " NULLS " + field.Nulls
+	}
+
+	return append(orders, order)
+}
+
+// orderClause builds the ORDER BY of the fields for the dialect
+func orderClause(fields []sortField, dialect string, table string) string {
+	orders := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		orders = append(orders, field.orders(dialect, table)...)
+	}
+
+	return strings.Join(orders, ", ")
+}
+
+// orderScope adds the joins of the fields and the dialect specific ORDER BY
+func orderScope(db *gorm.DB, fields []sortField) *gorm.DB {
+	joins := map[string]bool{}
+	table := ""
+
+	for _, field := range fields {
+		if len(field.Join) > 0 && !joins[field.Join] {
+			joins[field.Join] = true
+			db = db.Joins(field.Join)
+		}
+	}
+
+	// root columns are qualified when other tables are joined
+	if len(joins) > 0 {
+		table = statementTable(db)
+	}
+
+	for _, field := range fields {
+		rootColumn := !strings.Contains(field.Column, ".") && len(field.JSONPath) == 0
+
+		if len(joins) > 0 && len(table) == 0 && rootColumn && len(field.Nulls) == 0 {
+			db = db.Order(clause.OrderByColumn{
+				Column: clause.Column{Table: clause.CurrentTable, Name: field.Column},
+				Desc:   field.Direction == "DESC",
+			})
+			continue
+		}
+
+		for _, order := range field.orders(db.Dialector.Name(), table) {
+			db = db.Order(order)
+		}
+	}
+
+	return db
+}
+
+// statementTable returns the table of the query from the table name or the model
+func statementTable(db *gorm.DB) string {
+	tx := db.Session(&gorm.Session{})
+
+	if len(tx.Statement.Table) == 0 && tx.Statement.Model != nil {
+		if err := tx.Statement.Parse(tx.Statement.Model); err != nil {
+			return ""
+		}
+	}
+
+	return tx.Statement.Table
+}
@@ -18,15 +18,22 @@ type Pagination struct {
 	PageSize   string `json:"pageSize"`
 	PageOrder  string `json:"pageOrder"`
 	Search     string `json:"q"`
+	//pageCursor will take precedence over pageOrder for cursor pagination
+	PageCursor string `json:"pageCursor"`
+	//pageCount selects the count mode: exact, estimated, capped or none
+	PageCount string `json:"pageCount"`
+	//Config overrides the service pagination config for the route
+	Config *PaginationConfig `json:"-"`
 }
 
 //Page - Valid page object created from Pagination request
 type Page struct {
-	Number int
-	Offset int
-	Size   int
-	Order  string
-	Search string
+	Number    int
+	Offset    int
+	Size      int
+	Order     string
+	Search    string
+	CountMode string
 }
 
 // PageResult - Pagination output to API
@@ -38,16 +45,30 @@ type PageResult struct {
 	TotalPages int64 `json:"totalPages"`
 	IsFirst    int   `json:"isFirst"`
 	IsLast     int   `json:"isLast"`
+	HasMore    bool  `json:"hasMore"`
+	//CountMode tells how TotalCount was computed, estimated or capped counts are approximate
+	CountMode string `json:"countMode,omitempty"`
+	//Links are set by the page responses from the request url
+	Links *PageLinks `json:"links,omitempty"`
 }
 
 //Paginator - Populate pagination object from request query
 func Paginator(c *gin.Context) Pagination {
 	var page Pagination
 
+	if value, ok := c.Get("paginationConfig"); ok {
+		if config, ok := value.(PaginationConfig); ok {
+			page.Config = &config
+		}
+	}
+
 	page.PageNumber = c.DefaultQuery("pageNumber", "1")
-	page.PageSize = c.DefaultQuery("pageSize", "25")
+	page.PageSize = c.DefaultQuery("pageSize", strconv.Itoa(page.getConfig().DefaultSize))
 	page.PageOrder = c.DefaultQuery("pageOrder", "")
 	page.PageOffset = c.DefaultQuery("pageOffset", "")
+	page.PageCursor = c.DefaultQuery("pageCursor", "")
+	page.Search = c.DefaultQuery("q", "")
+	page.PageCount = c.DefaultQuery("pageCount", "")
 
 	return page
 }
@@ -73,7 +94,10 @@ func PageInfo(pagination Pagination, totalCount int64) PageResult {
 		pageResult.IsFirst = 0
 	}
 
-	if (pageResult.PageOffset + pageResult.PageSize) > int(totalCount) {
+	pageResult.HasMore = int64(pageResult.PageNumber) < pageResult.TotalPages
+
+	//PageOffset is 1-based
+	if (pageResult.PageOffset - 1 + pageResult.PageSize) >= int(totalCount) {
 		pageResult.IsLast = 1
 	}
 
@@ -84,14 +108,19 @@ func PageInfo(pagination Pagination, totalCount int64) PageResult {
 func ValidPage(pagination Pagination, allowedFields map[string]interface{}) Page {
 	var page Page
 
+	config := pagination.getConfig()
 	page.Offset, _ = strconv.Atoi(pagination.PageOffset)
 	page.Size, _ = strconv.Atoi(pagination.PageSize)
 	page.Number, _ = strconv.Atoi(pagination.PageNumber)
 	page.Order = ""
+	page.Search = strings.TrimSpace(pagination.Search)
 
-	//Todo: Get configuration from external
 	if page.Size <= 0 {
-		page.Size = 25
+		page.Size = config.DefaultSize
+	}
+
+	if page.Size > config.MaxSize {
+		page.Size = config.MaxSize
 	}
 
 	if page.Number <= 0 {
@@ -102,99 +131,129 @@ func ValidPage(pagination Pagination, allowedFields map[string]interface{}) Page
 		page.Number = ((page.Offset - 1) / page.Size) + 1
 	}
 
-	//Todo: Get configuration from external
-	if page.Size > 500 {
-		page.Size = 500
-	}
-
 	if page.Offset <= 0 {
 		page.Offset = ((page.Number - 1) * page.Size) + 1
 	}
 
-	page.Order = parseOrder(pagination.PageOrder, allowedFields)
+	page.Order = parseOrder(pagination.PageOrder, allowedFields, config)
+	page.CountMode = validCountMode(pagination.PageCount, config.CountMode)
 
 	return page
 }
 
-func parseOrder(order string, allowedFields map[string]interface{}) string {
-	var orderBy string
-	var orderFieldWithSort []string
-	var orderFieldSnake string
+func parseOrder(order string, allowedFields map[string]interface{}, config PaginationConfig) string {
+	return orderClause(parseOrderFields(order, allowedFields, config), "", "")
+}
 
+// parseOrderFields returns the allowed order fields of "field [ASC|DESC] [NULLS FIRST|LAST], ..."
+// followed by the configured tie breaker column so that the row order is stable across pages
+func parseOrderFields(order string, allowedFields map[string]interface{}, config PaginationConfig) []sortField {
 	if len(allowedFields) == 0 {
-		allowedFields = map[string]interface{}{
-			"id":         "true",
-			"name":       "true",
-			"code":       "true",
-			"created_at": "true",
-			"updated_at": "true",
-		}
+		allowedFields = config.AllowedSortFields
+	}
+
+	if len(order) == 0 {
+		order = config.DefaultOrder
 	}
 
-	orderString := ""
+	fields := make([]sortField, 0)
 
 	if len(order) == 0 {
-		return orderString
+		return fields
 	}
 
 	orders := strings.Split(order, ",")
+	hasTieBreaker := false
 
 	for _, orderField := range orders {
-		orderField = strings.TrimSpace(orderField)
-		orderFieldWithSort = strings.Split(orderField, " ")
-
-		if len(orderFieldWithSort) >= 1 {
-			if len(orderFieldWithSort) == 1 {
-				orderBy = "DESC"
-			} else {
-				orderBy = orderFieldWithSort[1]
-				orderBy = strings.TrimSpace(orderBy)
-				orderBy = strings.ToUpper(orderBy)
-			}
+		orderFieldWithSort := strings.Fields(orderField)
 
-			orderField = strings.TrimSpace(orderFieldWithSort[0])
-			orderFieldSnake = strcase.ToSnake(orderField)
+		if len(orderFieldWithSort) == 0 {
+			continue
+		}
 
-			if orderBy != "ASC" && orderBy != "DESC" {
-				orderBy = "DESC"
-			}
+		orderBy := config.DefaultDirection
+		if len(orderFieldWithSort) > 1 {
+			orderBy = strings.ToUpper(orderFieldWithSort[1])
+		}
+
+		if orderBy != "ASC" && orderBy != "DESC" {
+			orderBy = config.DefaultDirection
+		}
+
+		nulls := ""
+		if len(orderFieldWithSort) > 3 && strings.ToUpper(orderFieldWithSort[2]) == "NULLS" {
+			nulls = validNulls(orderFieldWithSort[3])
+		}
+
+		orderFieldSnake := strcase.ToSnake(orderFieldWithSort[0])
+		field := sortField{Column: orderFieldSnake, Direction: orderBy, Nulls: nulls}
 
-			if value, ok := allowedFields[orderFieldSnake]; ok && value == "true" {
-				if orderString == "" {
-					orderString = orderFieldSnake + " " + orderBy
-				} else {
-					orderString = orderString + ", " + orderFieldSnake + " " + orderBy
-				}
+		switch value := allowedFields[orderFieldSnake].(type) {
+		case string:
+			if value != "true" {
+				continue
 			}
+		case SortField:
+			if len(value.Column) > 0 {
+				field.Column = value.Column
+			}
+			if len(field.Nulls) == 0 {
+				field.Nulls = validNulls(value.Nulls)
+			}
+			field.Join = value.Join
+			field.JSONPath = value.JSONPath
+		default:
+			continue
+		}
+
+		if field.Column == config.TieBreaker {
+			hasTieBreaker = true
 		}
+
+		fields = append(fields, field)
 	}
 
-	return orderString
-}
+	if len(fields) > 0 && !hasTieBreaker && len(config.TieBreaker) > 0 && config.TieBreaker != "-" {
+		fields = append(fields, sortField{
+			Column:    config.TieBreaker,
+			Direction: fields[len(fields)-1].Direction,
+		})
+	}
 
-//Paginate - Do the db pagination query
-func Paginate(pagination Pagination) func(db *gorm.DB) *gorm.DB {
-	page := ValidPage(pagination, map[string]interface{}{})
+	return fields
+}
 
-	return func(db *gorm.DB) *gorm.DB {
-		db = db.Offset(page.Offset - 1).Limit(page.Size)
-		if len(page.Order) > 0 {
-			db = db.Order(page.Order)
-		}
+func validNulls(nulls string) string {
+	nulls = strings.ToUpper(nulls)
 
-		return db
+	if nulls == "FIRST" || nulls == "LAST" {
+		return nulls
 	}
+
+	return ""
+}
+
+//Paginate - Do the db pagination query
+func Paginate(pagination Pagination) func(db *gorm.DB) *gorm.DB {
+	return PaginateWithAllowedFields(pagination, map[string]interface{}{})
 }
 
 func PaginateWithAllowedFields(pagination Pagination, allowedFields map[string]interface{}) func(db *gorm.DB) *gorm.DB {
 	page := ValidPage(pagination, allowedFields)
+	fields := parseOrderFields(pagination.PageOrder, allowedFields, pagination.getConfig())
 
 	return func(db *gorm.DB) *gorm.DB {
-		db = db.Offset(page.Offset - 1).Limit(page.Size)
-		if len(page.Order) > 0 {
-			db = db.Order(page.Order)
-		}
+		db = db.Offset(page.Offset - 1).Limit(page.fetchSize())
 
-		return db
+		return orderScope(db, fields)
 	}
 }
+
+// scopeError fails the query with the error without touching the shared db instance
+func scopeError(db *gorm.DB, err error) *gorm.DB {
+	tx := db.Where("1 = 0")
+	tx.AddError(err)
+
+	return tx
+}
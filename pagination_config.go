@@ -0,0 +1,129 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// PaginationConfig - Pagination defaults and limits of the service or route.
+// Zero values of a route config fall back to the service config.
+type PaginationConfig struct {
+	DefaultSize       int
+	MaxSize           int
+	DefaultOrder      string
+	AllowedSortFields map[string]interface{}
+	DefaultDirection  string
+	CountMode         string
+	CountCap          int64
+	//TieBreaker is the unique column appended to the order so that the row order is stable
+	//across pages, e.g. "id". None by default, "-" disables the service tie breaker for a route
+	TieBreaker string
+}
+
+var paginationConfig *PaginationConfig
+
+// SetPaginationConfig - Set the service wide pagination config
+func SetPaginationConfig(config PaginationConfig) {
+	merged := config.merge(PaginationConfigFromEnv())
+	paginationConfig = &merged
+}
+
+// GetPaginationConfig - Get the service wide pagination config
+func GetPaginationConfig() PaginationConfig {
+	if paginationConfig != nil {
+		return *paginationConfig
+	}
+
+	return PaginationConfigFromEnv()
+}
+
+// PaginationConfigFromEnv - Read the pagination config from PAGINATION_DEFAULT_SIZE,
+// PAGINATION_MAX_SIZE, PAGINATION_DEFAULT_ORDER, PAGINATION_SORT_FIELDS (comma separated)
+// PAGINATION_DEFAULT_DIRECTION, PAGINATION_COUNT_MODE, PAGINATION_COUNT_CAP and PAGINATION_TIE_BREAKER
+func PaginationConfigFromEnv() PaginationConfig {
+	config := PaginationConfig{
+		DefaultOrder:      GetEnv("PAGINATION_DEFAULT_ORDER", ""),
+		DefaultDirection:  strings.ToUpper(GetEnv("PAGINATION_DEFAULT_DIRECTION", "DESC")),
+		AllowedSortFields: map[string]interface{}{},
+		TieBreaker:        GetEnv("PAGINATION_TIE_BREAKER", ""),
+	}
+
+	config.DefaultSize, _ = strconv.Atoi(GetEnv("PAGINATION_DEFAULT_SIZE", "25"))
+	config.MaxSize, _ = strconv.Atoi(GetEnv("PAGINATION_MAX_SIZE", "500"))
+	config.CountMode = validCountMode(GetEnv("PAGINATION_COUNT_MODE", CountExact), CountExact)
+	config.CountCap, _ = strconv.ParseInt(GetEnv("PAGINATION_COUNT_CAP", "10000"), 10, 64)
+
+	sortFields := GetEnv("PAGINATION_SORT_FIELDS", "id,name,code,created_at,updated_at")
+
+	for _, field := range strings.Split(sortFields, ",") {
+		if field = strings.TrimSpace(field); len(field) > 0 {
+			config.AllowedSortFields[strcase.ToSnake(field)] = "true"
+		}
+	}
+
+	if config.DefaultSize <= 0 {
+		config.DefaultSize = 25
+	}
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = 500
+	}
+
+	if config.DefaultDirection != "ASC" {
+		config.DefaultDirection = "DESC"
+	}
+
+	if config.CountCap <= 0 {
+		config.CountCap = 10000
+	}
+
+	return config
+}
+
+// merge fills the zero values from the base config
+func (config PaginationConfig) merge(base PaginationConfig) PaginationConfig {
+	if config.DefaultSize <= 0 {
+		config.DefaultSize = base.DefaultSize
+	}
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = base.MaxSize
+	}
+
+	if len(config.DefaultOrder) == 0 {
+		config.DefaultOrder = base.DefaultOrder
+	}
+
+	if len(config.AllowedSortFields) == 0 {
+		config.AllowedSortFields = base.AllowedSortFields
+	}
+
+	config.CountMode = validCountMode(config.CountMode, base.CountMode)
+
+	if len(config.TieBreaker) == 0 {
+		config.TieBreaker = base.TieBreaker
+	}
+
+	if config.CountCap <= 0 {
+		config.CountCap = base.CountCap
+	}
+
+	config.DefaultDirection = strings.ToUpper(config.DefaultDirection)
+
+	if config.DefaultDirection != "ASC" && config.DefaultDirection != "DESC" {
+		config.DefaultDirection = base.DefaultDirection
+	}
+
+	return config
+}
+
+// getConfig returns the route config of the pagination merged with the service config
+func (pagination Pagination) getConfig() PaginationConfig {
+	if pagination.Config != nil {
+		return pagination.Config.merge(GetPaginationConfig())
+	}
+
+	return GetPaginationConfig()
+}
@@ -0,0 +1,44 @@
+package common
+
+import "testing"
+
+func TestPaginateTieBreaker(t *testing.T) {
+	type Product struct {
+		Code string
+		Name string
+	}
+
+	allowedFields := map[string]interface{}{"name": "true", "code": "true"}
+
+	tests := []struct {
+		name       string
+		pagination Pagination
+		sql        string
+	}{
+		{
+			name:       "no tie breaker by default",
+			pagination: Pagination{PageSize: "10", PageOrder: "name asc"},
+			sql:        "SELECT * FROM `products` ORDER BY name ASC LIMIT 10",
+		},
+		{
+			name:       "configured tie breaker",
+			pagination: Pagination{PageSize: "10", PageOrder: "name asc", Config: &PaginationConfig{TieBreaker: "code"}},
+			sql:        "SELECT * FROM `products` ORDER BY name ASC,code ASC LIMIT 10",
+		},
+		{
+			name:       "tie breaker in the order",
+			pagination: Pagination{PageSize: "10", PageOrder: "code desc", Config: &PaginationConfig{TieBreaker: "code"}},
+			sql:        "SELECT * FROM `products` ORDER BY code DESC LIMIT 10",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt := dryRunDB(t).Scopes(PaginateWithAllowedFields(test.pagination, allowedFields)).Find(&[]Product{}).Statement
+
+			if sql := stmt.SQL.String(); sql != test.sql {
+				t.Errorf("sql = %s, want %s", sql, test.sql)
+			}
+		})
+	}
+}
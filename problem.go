@@ -0,0 +1,112 @@
+package common
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ErrorFormatEnvelope renders errors inside the Response envelope (default)
+	ErrorFormatEnvelope = "envelope"
+	// ErrorFormatProblem renders errors as RFC 7807 problem details
+	ErrorFormatProblem = "problem"
+
+	ProblemContentType = "application/problem+json"
+)
+
+// Service wide settings, fall back to ERROR_FORMAT and PROBLEM_TYPE_BASE_URI
+// which are read on use so that values loaded by LoadEnv are honoured
+var (
+	errorFormat     string
+	problemTypeBase string
+)
+
+// ProblemDetail - RFC 7807 error output to API
+type ProblemDetail struct {
+	Type      string        `json:"type" example:"about:blank"`
+	Title     string        `json:"title" example:"Bad Request"`
+	Status    int           `json:"status" example:"400"`
+	Detail    string        `json:"detail,omitempty" example:"Name field is required"`
+	Instance  string        `json:"instance,omitempty" example:"/products"`
+	Code      string        `json:"code,omitempty" example:"BAD_REQUEST"`
+	Errors    []ErrorDetail `json:"errors,omitempty"`
+	Data      interface{}   `json:"data,omitempty"`
+	RequestId string        `json:"requestId,omitempty" example:"3b6272b9-1ef1-45e0"`
+}
+
+// SetErrorFormat - Set the service wide error format (envelope or problem)
+func SetErrorFormat(format string) {
+	errorFormat = format
+}
+
+// SetProblemTypeBase - Set the base URI used to build the problem type from the error code
+func SetProblemTypeBase(baseURI string) {
+	problemTypeBase = baseURI
+}
+
+// WantsProblem - Check whether errors should be rendered as problem details for the request
+func WantsProblem(c *gin.Context) bool {
+	format := errorFormat
+	if len(format) == 0 {
+		format = GetEnv("ERROR_FORMAT", ErrorFormatEnvelope)
+	}
+
+	if format == ErrorFormatProblem {
+		return true
+	}
+
+	return strings.Contains(c.Request.Header.Get("Accept"), ProblemContentType)
+}
+
+// NewProblemDetail - Build the problem details from the error data
+func NewProblemDetail(c *gin.Context, status int, errorData *ErrorData) ProblemDetail {
+	problem := ProblemDetail{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: c.Request.URL.Path,
+	}
+
+	if errorData == nil {
+		return problem
+	}
+
+	typeBase := problemTypeBase
+	if len(typeBase) == 0 {
+		typeBase = GetEnv("PROBLEM_TYPE_BASE_URI", "")
+	}
+
+	if len(typeBase) > 0 && len(errorData.Code) > 0 {
+		problem.Type = strings.TrimRight(typeBase, "/") + "/" +
+			strings.ReplaceAll(strings.ToLower(errorData.Code), "_", "-")
+	}
+
+	problem.Code = errorData.Code
+	problem.Detail = errorData.Message
+	problem.Errors = errorData.Details
+
+	return problem
+}
+
+func renderError(c *gin.Context, status int, errorData interface{}, data interface{}) {
+	requestId := RequestID(c)
+
+	if errData, ok := errorData.(*ErrorData); ok && WantsProblem(c) {
+		problem := NewProblemDetail(c, status, errData)
+		problem.Data = data
+		problem.RequestId = requestId
+
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(status, problem)
+		return
+	}
+
+	c.JSON(status, Response{
+		Status:    status,
+		Data:      data,
+		Error:     errorData,
+		RequestId: requestId,
+	})
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		env     string
+		accept  string
+		problem bool
+	}{
+		{name: "default envelope", problem: false},
+		{name: "problem accepted", accept: "application/problem+json, application/json", problem: true},
+		{name: "json accepted", accept: "application/json", problem: false},
+		{name: "problem format from env", env: ErrorFormatProblem, problem: true},
+		{name: "envelope format from env", env: ErrorFormatEnvelope, accept: "application/problem+json", problem: true},
+		{name: "problem format setting", format: ErrorFormatProblem, env: ErrorFormatEnvelope, problem: true},
+		{name: "envelope format setting", format: ErrorFormatEnvelope, env: ErrorFormatProblem, problem: false},
+	}
+
+	defer os.Unsetenv("ERROR_FORMAT")
+	defer SetErrorFormat("")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv("ERROR_FORMAT", test.env)
+			SetErrorFormat(test.format)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/products", nil)
+			c.Request.Header.Set("Accept", test.accept)
+
+			if problem := WantsProblem(c); problem != test.problem {
+				t.Errorf("WantsProblem = %v, want %v", problem, test.problem)
+			}
+		})
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeBase string
+		env      string
+		code     string
+		typeURI  string
+	}{
+		{name: "without base uri", code: NOT_FOUND, typeURI: "about:blank"},
+		{name: "base uri from env", env: "https://errors.example.com/", code: NOT_FOUND, typeURI: "https://errors.example.com/not-found"},
+		{name: "base uri setting", typeBase: "https://api.example.com/errors", env: "https://errors.example.com", code: ALREADY_EXISTS, typeURI: "https://api.example.com/errors/already-exists"},
+		{name: "without code", env: "https://errors.example.com", typeURI: "about:blank"},
+	}
+
+	defer os.Unsetenv("PROBLEM_TYPE_BASE_URI")
+	defer SetProblemTypeBase("")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv("PROBLEM_TYPE_BASE_URI", test.env)
+			SetProblemTypeBase(test.typeBase)
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/products/1", nil)
+			c.Request.Header.Set("Accept", ProblemContentType)
+
+			ErrorResponseWitCode(c, http.StatusNotFound, &ErrorData{Code: test.code, Message: "Product not found"})
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, ProblemContentType)
+			}
+
+			var problem ProblemDetail
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ProblemDetail{
+				Type:     test.typeURI,
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "Product not found",
+				Instance: "/products/1",
+				Code:     test.code,
+			}
+
+			if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+				problem.Detail != want.Detail || problem.Instance != want.Instance || problem.Code != want.Code {
+				t.Errorf("problem = %+v, want %+v", problem, want)
+			}
+		})
+	}
+}
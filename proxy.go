@@ -0,0 +1,89 @@
+package common
+
+import (
+	"log"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Service wide trusted proxies, fall back to the comma separated TRUSTED_PROXIES
+// which is read on use so that values loaded by LoadEnv are honoured
+var trustedProxies []string
+
+// SetTrustedProxies - Set the addresses and CIDR ranges of the proxies whose forwarded
+// headers are used for the page links
+func SetTrustedProxies(proxies ...string) {
+	trustedProxies = proxies
+}
+
+// ParseTrustedProxies - Parse the addresses and CIDR ranges of the trusted proxies
+func ParseTrustedProxies(proxies []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if len(proxy) == 0 {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Println("Invalid trusted proxy " + proxy)
+			continue
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// TrustedProxy - Check whether the address is one of the trusted proxies
+func TrustedProxy(ip net.IP, proxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoteIP - Get the address of the connection peer
+func RemoteIP(c *gin.Context) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+
+	return net.ParseIP(host)
+}
+
+// fromTrustedProxy checks whether the request comes from one of the service wide trusted proxies
+func fromTrustedProxy(c *gin.Context) bool {
+	proxies := trustedProxies
+	if len(proxies) == 0 {
+		proxies = strings.Split(GetEnv("TRUSTED_PROXIES", ""), ",")
+	}
+
+	return TrustedProxy(RemoteIP(c), ParseTrustedProxies(proxies))
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID - Request id of the request set by the RequestID middleware,
+// falls back to the id or trace headers of the request
+func RequestID(c *gin.Context) string {
+	if id := c.GetString("requestId"); len(id) > 0 {
+		return id
+	}
+
+	if c.Request == nil {
+		return ""
+	}
+
+	return RequestIDFromHeader(c.Request.Header)
+}
+
+// RequestIDFromHeader - Read the request id from X-Request-Id, the trace id of the W3C traceparent
+// or of the B3 headers
+func RequestIDFromHeader(header http.Header) string {
+	if id := validRequestID(header.Get("X-Request-Id")); len(id) > 0 {
+		return id
+	}
+
+	//traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(header.Get("traceparent"), "-"); len(parts) >= 4 {
+		if id := validRequestID(parts[1]); len(id) > 0 && strings.Trim(id, "0") != "" {
+			return id
+		}
+	}
+
+	if id := validRequestID(header.Get("X-B3-Traceid")); len(id) > 0 {
+		return id
+	}
+
+	//b3: traceid-spanid-sampled-parentspanid
+	if parts := strings.Split(header.Get("b3"), "-"); len(parts) >= 2 {
+		return validRequestID(parts[0])
+	}
+
+	return ""
+}
+
+// NewRequestID - Generate a random request id in the trace id format
+func NewRequestID() string {
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// validRequestID drops ids which are too long or have characters unsafe for headers and logs
+func validRequestID(id string) string {
+	id = strings.TrimSpace(id)
+
+	if len(id) > 128 {
+		return ""
+	}
+
+	for _, char := range id {
+		if char <= ' ' || char > '~' {
+			return ""
+		}
+	}
+
+	return id
+}
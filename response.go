@@ -32,11 +32,11 @@ type ResponseWithFilter struct {
 }
 
 func SuccessResponse(c *gin.Context, key string, body interface{}) {
-	c.JSON(http.StatusOK, Response{
+	renderSuccess(c, http.StatusOK, key, Response{
 		Status:    http.StatusOK,
 		Data:      map[string]interface{}{key: body},
 		Error:     nil,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+		RequestId: RequestID(c),
 	})
 }
 
@@ -45,60 +45,49 @@ func EmptySuccessResponse(c *gin.Context) {
 		Status:    http.StatusOK,
 		Data:      nil,
 		Error:     nil,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+		RequestId: RequestID(c),
 	})
 }
 
 func SuccessPageResponse(c *gin.Context, key string, body interface{}, page interface{}) {
+	page = withPageLinks(c, page)
+
 	dataWithPage := ResponseWithPage{
 		Status:     http.StatusOK,
 		Data:       map[string]interface{}{key: body},
 		Error:      nil,
 		Pagination: page,
-		RequestId:  c.Request.Header.Get("X-B3-Traceid"),
+		RequestId:  RequestID(c),
 	}
 
-	c.JSON(http.StatusOK, dataWithPage)
+	renderSuccess(c, http.StatusOK, key, dataWithPage)
 }
 
 func SuccessPageFilterResponse(c *gin.Context, key string, body interface{}, filters interface{}, page interface{}) {
+	page = withPageLinks(c, page)
+
 	dataWithPage := ResponseWithFilter{
 		Status:     http.StatusOK,
 		Data:       map[string]interface{}{key: body},
 		Error:      nil,
 		Pagination: page,
 		Filters:    filters,
-		RequestId:  c.Request.Header.Get("X-B3-Traceid"),
+		RequestId:  RequestID(c),
 	}
 
-	c.JSON(http.StatusOK, dataWithPage)
+	renderSuccess(c, http.StatusOK, key, dataWithPage)
 }
 
 func ErrorResponseWitCode(c *gin.Context, errorCode int, errorData *ErrorData) {
-	c.JSON(errorCode, Response{
-		Status:    errorCode,
-		Data:      nil,
-		Error:     errorData,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
-	})
+	renderError(c, errorCode, errorData, nil)
 }
 
 func ErrorResponse(c *gin.Context, errorData *ErrorData) {
-	c.JSON(http.StatusBadRequest, Response{
-		Status:    http.StatusBadRequest,
-		Data:      nil,
-		Error:     errorData,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
-	})
+	renderError(c, http.StatusBadRequest, errorData, nil)
 }
 
 func BadRequest(c *gin.Context, errorData interface{}) {
-	c.JSON(http.StatusBadRequest, Response{
-		Status:    http.StatusBadRequest,
-		Data:      nil,
-		Error:     errorData,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
-	})
+	renderError(c, http.StatusBadRequest, errorData, nil)
 }
 
 func BadRequestWithMessage(c *gin.Context, errorMessage string) {
@@ -153,6 +142,45 @@ func ResourceNotFound(c *gin.Context, errorMessage string) {
 	ErrorResponseWitCode(c, http.StatusNotFound, errorData)
 }
 
+func NotAcceptable(c *gin.Context, errorMessage string) {
+	if len(errorMessage) == 0 {
+		errorMessage = "Requested media type is not supported"
+	}
+
+	errorData := &ErrorData{
+		Code:    NOT_ACCEPTABLE,
+		Message: errorMessage,
+	}
+
+	ErrorResponseWitCode(c, http.StatusNotAcceptable, errorData)
+}
+
+func PreconditionFailed(c *gin.Context, errorMessage string) {
+	if len(errorMessage) == 0 {
+		errorMessage = "Resource has been modified"
+	}
+
+	errorData := &ErrorData{
+		Code:    FAILED_PRECONDITION,
+		Message: errorMessage,
+	}
+
+	ErrorResponseWitCode(c, http.StatusPreconditionFailed, errorData)
+}
+
+func TooManyRequests(c *gin.Context, errorMessage string) {
+	if len(errorMessage) == 0 {
+		errorMessage = "Too many requests"
+	}
+
+	errorData := &ErrorData{
+		Code:    RESOURCE_EXHAUSTED,
+		Message: errorMessage,
+	}
+
+	ErrorResponseWitCode(c, http.StatusTooManyRequests, errorData)
+}
+
 func InternalServerError(c *gin.Context, errorMessage string) {
 	if len(errorMessage) == 0 {
 		errorMessage = "Internal server error"
@@ -170,7 +198,7 @@ func MultiStatusResponse(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusMultiStatus, Response{
 		Status:    http.StatusMultiStatus,
 		Data:      data,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+		RequestId: RequestID(c),
 	})
 }
 
@@ -178,25 +206,18 @@ func ProcessingStatusResponse(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusAccepted, Response{
 		Status:    http.StatusAccepted,
 		Data:      data,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+		RequestId: RequestID(c),
 	})
 }
 
 func ErrorResponseWitConflict(c *gin.Context, errorCode int, errorData *ErrorData, key string, body interface{}) {
+	var data interface{}
 
-	response := Response{
-		Status:    errorCode,
-		Error:     errorData,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+	if len(key) > 0 && body != nil {
+		data = map[string]interface{}{key: body}
 	}
 
-	if len(key) == 0 || body == nil {
-		response.Data = nil
-	} else {
-		response.Data = map[string]interface{}{key: body}
-	}
-
-	c.JSON(errorCode, response)
+	renderError(c, errorCode, errorData, data)
 }
 
 func BadRequestWithConflict(c *gin.Context, errorMessage string, key string, body interface{}) {
@@ -211,10 +232,24 @@ func BadRequestWithConflict(c *gin.Context, errorMessage string, key string, bod
 	ErrorResponseWitConflict(c, http.StatusConflict, errorData, key, body)
 }
 
+// VersionConflict - Respond 409 with the current entity under the key so that the client can merge
+func VersionConflict(c *gin.Context, errorMessage string, key string, body interface{}) {
+	if len(errorMessage) == 0 {
+		errorMessage = ErrVersionConflict.Message
+	}
+
+	errorData := &ErrorData{
+		Code:    ABORTED,
+		Message: errorMessage,
+		Details: ErrVersionConflict.Details,
+	}
+	ErrorResponseWitConflict(c, http.StatusConflict, errorData, key, body)
+}
+
 func SuccessStatusNoContent(c *gin.Context) {
 	c.JSON(http.StatusNoContent, Response{
 		Status:    http.StatusNoContent,
 		Error:     nil,
-		RequestId: c.Request.Header.Get("X-B3-Traceid"),
+		RequestId: RequestID(c),
 	})
 }
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SearchModeLike matches the term anywhere in the columns, case insensitive
+	SearchModeLike = "like"
+	// SearchModeFullText uses the MySQL FULLTEXT index of the columns
+	SearchModeFullText = "fulltext"
+	// SearchModeTsVector uses the Postgres text search on the columns
+	SearchModeTsVector = "tsvector"
+)
+
+// SearchConfig - Searchable columns and the search mode of the endpoint
+type SearchConfig struct {
+	Columns   []string
+	Mode      string
+	MinLength int
+}
+
+// SearchScope - Do the db search query for the q param across the configured columns.
+// Full text modes fall back to LIKE when the dialect does not support them.
+func SearchScope(pagination Pagination, config SearchConfig) func(db *gorm.DB) *gorm.DB {
+	term := strings.TrimSpace(pagination.Search)
+	minLength := config.MinLength
+
+	if minLength <= 0 {
+		minLength = 1
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if len(config.Columns) == 0 || utf8.RuneCountInString(term) < minLength {
+			return db
+		}
+
+		columns := strings.Join(config.Columns, ", ")
+		dialect := db.Dialector.Name()
+
+		if config.Mode == SearchModeFullText && dialect == "mysql" {
+			return db.Where("MATCH("+columns+") AGAINST (? IN NATURAL LANGUAGE MODE)", term)
+		}
+
+		if config.Mode == SearchModeTsVector && dialect == "postgres" {
+			return db.Where("to_tsvector('simple', concat_ws(' ', "+columns+")) @@ plainto_tsquery('simple', ?)", term)
+		}
+
+		like := "%" + escapeLike(strings.ToLower(term)) + "%"
+		conditions := make([]string, len(config.Columns))
+		vars := make([]interface{}, len(config.Columns))
+
+		for i, column := range config.Columns {
+			conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+			vars[i] = like
+		}
+
+		return db.Where("("+strings.Join(conditions, " OR ")+")", vars...)
+	}
+}
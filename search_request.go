@@ -0,0 +1,164 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchRequest - Search request body of POST search APIs. Filter takes field -> operator -> value
+// or field -> value for eq, e.g. {"filter": {"brand": "acme", "price": {"gte": 10}}}
+type SearchRequest struct {
+	PageNumber interface{}            `json:"pageNumber" swaggertype:"integer" example:"1"`
+	PageOffset interface{}            `json:"pageOffset" swaggertype:"integer"`
+	PageSize   interface{}            `json:"pageSize" swaggertype:"integer" example:"25"`
+	PageOrder  string                 `json:"pageOrder" example:"name asc"`
+	Search     string                 `json:"q"`
+	PageCursor string                 `json:"pageCursor"`
+	PageCount  string                 `json:"pageCount"`
+	Filter     map[string]interface{} `json:"filter"`
+}
+
+// SearchPaginator - Populate the pagination and the valid filters from the JSON body of a
+// search request. Query params are used for the values missing in the body.
+func SearchPaginator(c *gin.Context, allowedFilters map[string][]string) (Pagination, []Filter, *ErrorData) {
+	page := Paginator(c)
+	raw := filterQuery(c)
+
+	var request SearchRequest
+
+	if c.Request.Body != nil {
+		decoder := json.NewDecoder(c.Request.Body)
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&request); err != nil && err != io.EOF {
+			return page, nil, &ErrorData{
+				Code:    INVALID_ARGUMENT,
+				Message: "Invalid search request",
+				Details: []ErrorDetail{{
+					Code:    "Invalid",
+					Target:  "body",
+					Message: err.Error(),
+				}},
+			}
+		}
+	}
+
+	errorDetails := make([]ErrorDetail, 0)
+
+	for _, number := range []struct {
+		target string
+		value  interface{}
+	}{
+		{"pageNumber", request.PageNumber},
+		{"pageOffset", request.PageOffset},
+		{"pageSize", request.PageSize},
+	} {
+		if _, ok := searchNumber(number.value); !ok {
+			errorDetails = append(errorDetails, ErrorDetail{
+				Code:    "Invalid",
+				Target:  number.target,
+				Message: number.target + " must be a number",
+			})
+		}
+	}
+
+	if len(errorDetails) > 0 {
+		return page, nil, &ErrorData{
+			Code:    INVALID_ARGUMENT,
+			Message: "Invalid search request",
+			Details: errorDetails,
+		}
+	}
+
+	if value, _ := searchNumber(request.PageNumber); len(value) > 0 {
+		page.PageNumber = value
+	}
+
+	if value, _ := searchNumber(request.PageOffset); len(value) > 0 {
+		page.PageOffset = value
+	}
+
+	if value, _ := searchNumber(request.PageSize); len(value) > 0 {
+		page.PageSize = value
+	}
+
+	if len(request.PageOrder) > 0 {
+		page.PageOrder = request.PageOrder
+	}
+
+	if len(request.Search) > 0 {
+		page.Search = request.Search
+	}
+
+	if len(request.PageCursor) > 0 {
+		page.PageCursor = request.PageCursor
+	}
+
+	if len(request.PageCount) > 0 {
+		page.PageCount = request.PageCount
+	}
+
+	for field, value := range request.Filter {
+		if _, ok := raw[field]; !ok {
+			raw[field] = map[string]interface{}{}
+		}
+
+		operators, ok := value.(map[string]interface{})
+		if !ok {
+			raw[field][FilterEq] = searchFilterValue(value)
+			continue
+		}
+
+		for operator, operatorValue := range operators {
+			raw[field][operator] = searchFilterValue(operatorValue)
+		}
+	}
+
+	filters, errorData := BuildFilters(raw, allowedFilters)
+
+	return page, filters, errorData
+}
+
+// searchNumber returns the page number, offset or size of the body as string,
+// numbers may also be sent as strings like in the query params
+func searchNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case json.Number:
+		_, err := strconv.Atoi(v.String())
+		return v.String(), err == nil
+	case string:
+		if len(v) == 0 {
+			return "", true
+		}
+		_, err := strconv.Atoi(v)
+		return v, err == nil
+	}
+
+	return "", false
+}
+
+// searchFilterValue converts the JSON numbers of the body to int64 or float64
+func searchFilterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+		if number, err := v.Float64(); err == nil {
+			return number
+		}
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = searchFilterValue(item)
+		}
+		return values
+	}
+
+	return value
+}
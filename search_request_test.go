@@ -0,0 +1,118 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSearchPaginator(t *testing.T) {
+	allowedFilters := map[string][]string{
+		"brand": {},
+		"price": {FilterGte, FilterLte},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		pagination Pagination
+		filters    []Filter
+		targets    []string
+	}{
+		{
+			name:       "query values without body",
+			query:      "pageSize=10&pageOrder=name+asc",
+			pagination: Pagination{PageSize: "10", PageNumber: "1", PageOrder: "name asc"},
+		},
+		{
+			name:       "body overrides query",
+			query:      "pageSize=10&pageNumber=2&q=shoe",
+			body:       `{"pageSize": 25, "pageOrder": "price desc"}`,
+			pagination: Pagination{PageSize: "25", PageNumber: "2", PageOrder: "price desc", Search: "shoe"},
+		},
+		{
+			name:       "numbers as strings",
+			body:       `{"pageSize": "5", "pageNumber": "3"}`,
+			pagination: Pagination{PageSize: "5", PageNumber: "3"},
+		},
+		{
+			name:       "field to value filter",
+			body:       `{"filter": {"brand": "acme"}}`,
+			pagination: Pagination{PageSize: "25", PageNumber: "1"},
+			filters:    []Filter{{Field: "brand", Operator: FilterEq, Value: "acme", Column: "brand"}},
+		},
+		{
+			name:       "field to operator to value filter",
+			body:       `{"filter": {"price": {"gte": 10}}}`,
+			pagination: Pagination{PageSize: "25", PageNumber: "1"},
+			filters:    []Filter{{Field: "price", Operator: FilterGte, Value: int64(10), Column: "price"}},
+		},
+		{
+			name:       "body filter merged with query filter",
+			query:      "filter[price][lte]=99",
+			body:       `{"filter": {"price": {"gte": 10.5}}}`,
+			pagination: Pagination{PageSize: "25", PageNumber: "1"},
+			filters: []Filter{
+				{Field: "price", Operator: FilterGte, Value: 10.5, Column: "price"},
+				{Field: "price", Operator: FilterLte, Value: "99", Column: "price"},
+			},
+		},
+		{name: "operator not allowed", body: `{"filter": {"price": {"eq": 10}}}`, targets: []string{"price"}},
+		{name: "unknown filter field", body: `{"filter": {"secret": "x"}}`, targets: []string{"secret"}},
+		{name: "non-numeric page size", body: `{"pageSize": "ten"}`, targets: []string{"pageSize"}},
+		{name: "fractional page number", body: `{"pageNumber": 1.5}`, targets: []string{"pageNumber"}},
+		{name: "page size of another type", body: `{"pageSize": true, "pageNumber": [1]}`, targets: []string{"pageNumber", "pageSize"}},
+		{name: "invalid body", body: `{"pageSize":`, targets: []string{"body"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/search?"+escapeQuery(test.query), strings.NewReader(test.body))
+
+			pagination, filters, errorData := SearchPaginator(c, allowedFilters)
+
+			if len(test.targets) > 0 {
+				if errorData == nil {
+					t.Fatalf("no error, want error for %v", test.targets)
+				}
+
+				targets := make([]string, 0, len(errorData.Details))
+				for _, detail := range errorData.Details {
+					targets = append(targets, detail.Target)
+				}
+
+				if !reflect.DeepEqual(targets, test.targets) {
+					t.Errorf("targets = %v, want %v", targets, test.targets)
+				}
+				return
+			}
+
+			if errorData != nil {
+				t.Fatalf("error = %+v", errorData)
+			}
+
+			got := Pagination{
+				PageSize:   pagination.PageSize,
+				PageNumber: pagination.PageNumber,
+				PageOrder:  pagination.PageOrder,
+				Search:     pagination.Search,
+			}
+
+			if got != test.pagination {
+				t.Errorf("pagination = %+v, want %+v", got, test.pagination)
+			}
+
+			if len(filters) > 0 || len(test.filters) > 0 {
+				if !reflect.DeepEqual(filters, test.filters) {
+					t.Errorf("filters = %#v, want %#v", filters, test.filters)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// namedDialector builds the SQL of the dialect name without a database
+type namedDialector struct {
+	dryRunDialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+func TestSearchScope(t *testing.T) {
+	type Product struct {
+		ID   uint
+		Name string
+	}
+
+	columns := []string{"name", "description"}
+
+	tests := []struct {
+		name    string
+		dialect string
+		search  string
+		config  SearchConfig
+		sql     string
+		vars    []interface{}
+	}{
+		{
+			name:   "like on the columns",
+			search: "Shoe",
+			config: SearchConfig{Columns: columns},
+			sql:    "SELECT * FROM `products` WHERE (LOWER(name) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!')",
+			vars:   []interface{}{"%shoe%", "%shoe%"},
+		},
+		{
+			name:   "escaped wildcards",
+			search: "50%_off!",
+			config: SearchConfig{Columns: []string{"name"}},
+			sql:    "SELECT * FROM `products` WHERE (LOWER(name) LIKE ? ESCAPE '!')",
+			vars:   []interface{}{"%50!%!_off!!%"},
+		},
+		{
+			name:   "shorter than the minimum length",
+			search: " ab ",
+			config: SearchConfig{Columns: columns, MinLength: 3},
+			sql:    "SELECT * FROM `products`",
+		},
+		{
+			name:   "minimum length in characters",
+			search: "äöü",
+			config: SearchConfig{Columns: []string{"name"}, MinLength: 3},
+			sql:    "SELECT * FROM `products` WHERE (LOWER(name) LIKE ? ESCAPE '!')",
+			vars:   []interface{}{"%äöü%"},
+		},
+		{
+			name:   "no columns",
+			search: "shoe",
+			config: SearchConfig{},
+			sql:    "SELECT * FROM `products`",
+		},
+		{
+			name:    "mysql full text",
+			dialect: "mysql",
+			search:  "shoe",
+			config:  SearchConfig{Columns: columns, Mode: SearchModeFullText},
+			sql:     "SELECT * FROM `products` WHERE MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)",
+			vars:    []interface{}{"shoe"},
+		},
+		{
+			name:    "full text falls back to like",
+			dialect: "postgres",
+			search:  "shoe",
+			config:  SearchConfig{Columns: []string{"name"}, Mode: SearchModeFullText},
+			sql:     "SELECT * FROM `products` WHERE (LOWER(name) LIKE ? ESCAPE '!')",
+			vars:    []interface{}{"%shoe%"},
+		},
+		{
+			name:    "postgres text search",
+			dialect: "postgres",
+			search:  "shoe",
+			config:  SearchConfig{Columns: columns, Mode: SearchModeTsVector},
+			sql:     "SELECT * FROM `products` WHERE to_tsvector('simple', concat_ws(' ', name, description)) @@ plainto_tsquery('simple', ?)",
+			vars:    []interface{}{"shoe"},
+		},
+		{
+			name:    "text search falls back to like",
+			dialect: "mysql",
+			search:  "shoe",
+			config:  SearchConfig{Columns: []string{"name"}, Mode: SearchModeTsVector},
+			sql:     "SELECT * FROM `products` WHERE (LOWER(name) LIKE ? ESCAPE '!')",
+			vars:    []interface{}{"%shoe%"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := gorm.Open(namedDialector{name: test.dialect}, &gorm.Config{DryRun: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stmt := db.Scopes(SearchScope(Pagination{Search: test.search}, test.config)).Find(&[]Product{}).Statement
+
+			if sql := stmt.SQL.String(); sql != test.sql {
+				t.Errorf("sql = %s, want %s", sql, test.sql)
+			}
+
+			if len(stmt.Vars) > 0 || len(test.vars) > 0 {
+				if !reflect.DeepEqual(stmt.Vars, test.vars) {
+					t.Errorf("vars = %#v, want %#v", stmt.Vars, test.vars)
+				}
+			}
+		})
+	}
+}
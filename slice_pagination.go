@@ -0,0 +1,167 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// PaginateSlice - Apply the pagination, pageOrder and q search of the request to a slice
+// of structs or maps loaded from other services. Fields are matched by their json names,
+// searchFields lists the fields matched case insensitively by q, q is ignored without them.
+func PaginateSlice(items interface{}, pagination Pagination, allowedFields map[string]interface{}, searchFields []string) (interface{}, PageResult) {
+	value := reflect.ValueOf(items)
+
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return items, PageInfo(pagination, 0)
+	}
+
+	page := ValidPage(pagination, allowedFields)
+	orderFields := parseOrderFields(pagination.PageOrder, allowedFields, pagination.getConfig())
+	term := strings.ToLower(page.Search)
+
+	searchColumns := make([]string, len(searchFields))
+	for i, field := range searchFields {
+		searchColumns[i] = strcase.ToSnake(field)
+	}
+
+	indexes := make([]int, 0, value.Len())
+	rows := make([]map[string]interface{}, value.Len())
+
+	for i := 0; i < value.Len(); i++ {
+		rows[i] = sliceRow(value.Index(i).Interface())
+
+		if len(term) > 0 && len(searchColumns) > 0 && !sliceRowMatches(rows[i], searchColumns, term) {
+			continue
+		}
+
+		indexes = append(indexes, i)
+	}
+
+	if len(orderFields) > 0 {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			left, right := rows[indexes[i]], rows[indexes[j]]
+
+			for _, field := range orderFields {
+				leftValue, rightValue := left[field.Column], right[field.Column]
+
+				// NULLS FIRST or LAST places the nil values regardless of the direction
+				if len(field.Nulls) > 0 && (leftValue == nil) != (rightValue == nil) {
+					return (leftValue == nil) == (field.Nulls == "FIRST")
+				}
+
+				result := compareSliceValues(leftValue, rightValue)
+				if result == 0 {
+					continue
+				}
+
+				if field.Direction == "DESC" {
+					return result > 0
+				}
+				return result < 0
+			}
+
+			return false
+		})
+	}
+
+	start := page.Offset - 1
+	if start > len(indexes) {
+		start = len(indexes)
+	}
+
+	end := start + page.Size
+	if end > len(indexes) {
+		end = len(indexes)
+	}
+
+	result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), 0, end-start)
+	for _, index := range indexes[start:end] {
+		result = reflect.Append(result, value.Index(index))
+	}
+
+	return result.Interface(), PageInfo(pagination, int64(len(indexes)))
+}
+
+// sliceRow converts the item to a map keyed by the snake case json field names
+func sliceRow(item interface{}) map[string]interface{} {
+	row := map[string]interface{}{}
+	generic, err := toGeneric(item)
+
+	if err != nil {
+		return row
+	}
+
+	if fields, ok := generic.(map[string]interface{}); ok {
+		for key, value := range fields {
+			row[strcase.ToSnake(key)] = value
+		}
+	}
+
+	return row
+}
+
+func sliceRowMatches(row map[string]interface{}, columns []string, term string) bool {
+	for _, column := range columns {
+		if value, ok := row[column]; ok && value != nil {
+			if strings.Contains(strings.ToLower(fmt.Sprint(value)), term) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// compareSliceValues compares json values, nil sorts first like NULL in ascending order
+func compareSliceValues(left interface{}, right interface{}) int {
+	if left == nil || right == nil {
+		switch {
+		case left == nil && right == nil:
+			return 0
+		case left == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	leftNumber, leftOk := left.(json.Number)
+	rightNumber, rightOk := right.(json.Number)
+
+	if leftOk && rightOk {
+		l, _ := leftNumber.Float64()
+		r, _ := rightNumber.Float64()
+
+		switch {
+		case l < r:
+			return -1
+		case l > r:
+			return 1
+		}
+		return 0
+	}
+
+	leftBool, leftOk := left.(bool)
+	rightBool, rightOk := right.(bool)
+
+	if leftOk && rightOk {
+		switch {
+		case leftBool == rightBool:
+			return 0
+		case !leftBool:
+			return -1
+		}
+		return 1
+	}
+
+	return strings.Compare(strings.ToLower(fmt.Sprint(left)), strings.ToLower(fmt.Sprint(right)))
+}
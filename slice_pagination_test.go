@@ -0,0 +1,80 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginateSlice(t *testing.T) {
+	type item struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Score *float64 `json:"score"`
+	}
+
+	score := func(value float64) *float64 {
+		return &value
+	}
+
+	items := []item{
+		{ID: 1, Name: "Apple", Score: score(2)},
+		{ID: 2, Name: "Banana"},
+		{ID: 3, Name: "Cherry", Score: score(1)},
+	}
+
+	allowedFields := map[string]interface{}{"name": "true", "score": "true"}
+
+	tests := []struct {
+		name         string
+		pagination   Pagination
+		searchFields []string
+		ids          []int
+	}{
+		{
+			name:         "search the fields",
+			pagination:   Pagination{PageSize: "10", Search: "an", PageOrder: "name asc"},
+			searchFields: []string{"name"},
+			ids:          []int{2},
+		},
+		{
+			name:       "search without fields is ignored",
+			pagination: Pagination{PageSize: "10", Search: "an", PageOrder: "name asc"},
+			ids:        []int{1, 2, 3},
+		},
+		{
+			name:       "nil sorts first ascending",
+			pagination: Pagination{PageSize: "10", PageOrder: "score asc"},
+			ids:        []int{2, 3, 1},
+		},
+		{
+			name:       "nulls last ascending",
+			pagination: Pagination{PageSize: "10", PageOrder: "score asc nulls last"},
+			ids:        []int{3, 1, 2},
+		},
+		{
+			name:       "nulls first descending",
+			pagination: Pagination{PageSize: "10", PageOrder: "score desc nulls first"},
+			ids:        []int{2, 1, 3},
+		},
+		{
+			name:       "page of the sorted items",
+			pagination: Pagination{PageSize: "1", PageNumber: "2", PageOrder: "name desc"},
+			ids:        []int{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, _ := PaginateSlice(items, test.pagination, allowedFields, test.searchFields)
+
+			ids := make([]int, 0)
+			for _, row := range result.([]item) {
+				ids = append(ids, row.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.ids) {
+				t.Errorf("ids = %v, want %v", ids, test.ids)
+			}
+		})
+	}
+}
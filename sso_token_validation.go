@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
@@ -48,31 +50,152 @@ func ValidateSSOToken(tokenStr string, ssoJwksUri string) (bool, error) {
 }
 
 func GetSSOPemCert(token *jwt.Token, ssoJwksUri string) (string, error) {
-	cert := ""
-	resp, err := http.Get(ssoJwksUri)
+	kid, _ := token.Header["kid"].(string)
 
+	key, err := jwksKey(ssoJwksUri, kid)
 	if err != nil {
-		return cert, err
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	var jwks = Jwks{}
-	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if len(key.X5c) == 0 {
+		return "", errors.New("unable to find appropriate key")
+	}
 
-	if err != nil {
-		return cert, err
+	return "-----BEGIN CERTIFICATE-----\n" + key.X5c[0] + "\n-----END CERTIFICATE-----", nil
+}
+
+const (
+	// jwksTTL is the time the keys of the JWKS uri are cached
+	jwksTTL = time.Hour
+	// jwksRefreshInterval limits the refreshes for unknown key ids
+	jwksRefreshInterval = 30 * time.Second
+)
+
+var (
+	jwksClient  = &http.Client{Timeout: 10 * time.Second}
+	jwksMutex   sync.Mutex
+	jwksCache   = map[string]jwksEntry{}
+	jwksFetches = map[string]*jwksFetch{}
+)
+
+type jwksEntry struct {
+	keys    map[string]JSONWebKeys
+	fetched time.Time
+}
+
+// jwksFetch is the fetch in progress of the JWKS uri which the other validations wait for
+type jwksFetch struct {
+	done  chan struct{}
+	entry jwksEntry
+	err   error
+}
+
+// jwksKey returns the key of the kid from the cached keys of the JWKS uri. The keys are fetched
+// again when they expire or the kid is unknown, e.g. after the identity provider rotated its keys.
+// The keys are fetched once for the concurrent validations without holding the cache lock.
+func jwksKey(ssoJwksUri string, kid string) (JSONWebKeys, error) {
+	jwksMutex.Lock()
+
+	entry, cached := jwksCache[ssoJwksUri]
+	age := time.Since(entry.fetched)
+
+	if cached && age < jwksTTL {
+		if key, ok := entry.keys[kid]; ok {
+			jwksMutex.Unlock()
+			return key, nil
+		}
+
+		if age < jwksRefreshInterval {
+			jwksMutex.Unlock()
+			return JSONWebKeys{}, errors.New("unable to find appropriate key")
+		}
+	}
+
+	fetch, fetching := jwksFetches[ssoJwksUri]
+	if !fetching {
+		fetch = &jwksFetch{done: make(chan struct{})}
+		jwksFetches[ssoJwksUri] = fetch
 	}
 
-	for k, _ := range jwks.Keys {
-		if token.Header["kid"] == jwks.Keys[k].Kid {
-			cert = "-----BEGIN CERTIFICATE-----\n" + jwks.Keys[k].X5c[0] + "\n-----END CERTIFICATE-----"
+	jwksMutex.Unlock()
+
+	if fetching {
+		<-fetch.done
+	} else {
+		fetch.entry, fetch.err = loadJwks(ssoJwksUri)
+
+		jwksMutex.Lock()
+		if fetch.err == nil {
+			jwksCache[ssoJwksUri] = fetch.entry
 		}
+		delete(jwksFetches, ssoJwksUri)
+		jwksMutex.Unlock()
+
+		close(fetch.done)
 	}
 
-	if cert == "" {
-		err := errors.New("unable to find appropriate key")
-		return cert, err
+	if fetch.err != nil {
+		// the expired key is used while the identity provider is not reachable
+		if key, ok := entry.keys[kid]; ok {
+			return key, nil
+		}
+
+		return JSONWebKeys{}, fetch.err
 	}
 
-	return cert, nil
+	key, ok := fetch.entry.keys[kid]
+	if !ok {
+		return key, errors.New("unable to find appropriate key")
+	}
+
+	return key, nil
+}
+
+// loadJwks fetches the keys of the JWKS uri by key id
+func loadJwks(ssoJwksUri string) (jwksEntry, error) {
+	jwks, err := fetchJwks(ssoJwksUri)
+	if err != nil {
+		return jwksEntry{}, err
+	}
+
+	entry := jwksEntry{keys: map[string]JSONWebKeys{}, fetched: time.Now()}
+	for _, key := range jwks.Keys {
+		entry.keys[key.Kid] = key
+	}
+
+	return entry, nil
+}
+
+func fetchJwks(ssoJwksUri string) (Jwks, error) {
+	var jwks = Jwks{}
+
+	resp, err := jwksClient.Get(ssoJwksUri)
+	if err != nil {
+		return jwks, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jwks, errors.New("unable to fetch the keys: " + resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+
+	return jwks, err
+}
+
+// SSOKeyFunc - Key function verifying the RS256 token with the key of the JWKS uri
+func SSOKeyFunc(ssoJwksUri string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		cert, err := GetSSOPemCert(token, ssoJwksUri)
+		if err != nil {
+			return nil, err
+		}
+
+		return jwt.ParseRSAPublicKeyFromPEM([]byte(cert))
+	}
 }
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJwksKeyCache(t *testing.T) {
+	var fetches int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(Jwks{Keys: []JSONWebKeys{{Kid: "key-1", X5c: []string{"cert"}}}})
+	}))
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := jwksKey(server.URL, "key-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("fetches = %d, want 1 for the cached key", fetches)
+	}
+
+	// the unknown key is not fetched again right after the refresh
+	if _, err := jwksKey(server.URL, "key-2"); err == nil {
+		t.Error("unknown key is found")
+	}
+
+	if fetches != 1 {
+		t.Errorf("fetches = %d, want 1 within the refresh interval", fetches)
+	}
+}
+
+func TestJwksKeySlowFetch(t *testing.T) {
+	var fetches int32
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		json.NewEncoder(w).Encode(Jwks{Keys: []JSONWebKeys{{Kid: "key-1", X5c: []string{"cert"}}}})
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Jwks{Keys: []JSONWebKeys{{Kid: "key-1", X5c: []string{"cert"}}}})
+	}))
+	defer fast.Close()
+
+	if _, err := jwksKey(fast.URL, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := jwksKey(slow.URL, "key-1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	for atomic.LoadInt32(&fetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the cached keys of another uri do not wait for the slow fetch
+	done := make(chan error, 1)
+	go func() {
+		_, err := jwksKey(fast.URL, "key-1")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("cached key waits for the fetch of another uri")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("fetches = %d, want 1 for the concurrent validations", fetches)
+	}
+}
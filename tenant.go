@@ -0,0 +1,119 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TenantActive    = "active"
+	TenantSuspended = "suspended"
+)
+
+// Tenant - Tenant metadata set in the context by the tenant middleware
+type Tenant struct {
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Status   string                 `json:"status"`
+	Locale   string                 `json:"locale"`
+	Timezone string                 `json:"timezone"`
+	Plan     string                 `json:"plan"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TenantStore - Lookup of the tenants, nil tenant without error for an unknown tenant
+type TenantStore interface {
+	GetTenant(ctx context.Context, tenantID string) (*Tenant, error)
+}
+
+// ErrUnknownTenant - Tenant of the request does not exist
+var ErrUnknownTenant = &AppError{
+	Code:    NOT_FOUND,
+	Message: "Tenant not found",
+	Status:  http.StatusNotFound,
+	Details: []ErrorDetail{{Code: "UnknownTenant", Target: "tenantId", Message: "Tenant does not exist"}},
+}
+
+// ErrTenantSuspended - Tenant of the request is not active
+var ErrTenantSuspended = &AppError{
+	Code:    ACCESS_DENIED,
+	Message: "Tenant is suspended",
+	Status:  http.StatusForbidden,
+	Details: []ErrorDetail{{Code: "TenantSuspended", Target: "tenantId", Message: "Tenant is not active"}},
+}
+
+// CurrentTenant - Tenant of the request resolved by the tenant middleware with a store
+func CurrentTenant(c *gin.Context) *Tenant {
+	if value, ok := c.Get("tenant"); ok {
+		if tenant, ok := value.(*Tenant); ok {
+			return tenant
+		}
+	}
+
+	return nil
+}
+
+// CachedTenantStore - Cache the tenants of the store in memory for the ttl
+type CachedTenantStore struct {
+	store   TenantStore
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedTenant
+}
+
+type cachedTenant struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+// NewCachedTenantStore - Create the cache of the tenant store, unknown tenants are cached too
+func NewCachedTenantStore(store TenantStore, ttl time.Duration) *CachedTenantStore {
+	return &CachedTenantStore{
+		store:   store,
+		ttl:     ttl,
+		entries: map[string]cachedTenant{},
+	}
+}
+
+// GetTenant - Get the tenant from the cache or the store
+func (s *CachedTenantStore) GetTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.entries[tenantID]
+	s.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		return entry.tenant, nil
+	}
+
+	tenant, err := s.store.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, cached := range s.entries {
+		if now.After(cached.expires) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.entries[tenantID] = cachedTenant{tenant: tenant, expires: now.Add(s.ttl)}
+
+	return tenant, nil
+}
+
+// Invalidate - Drop the cached tenant after it was changed
+func (s *CachedTenantStore) Invalidate(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, tenantID)
+}